@@ -0,0 +1,147 @@
+// Package cellwidth computes how many terminal cells a text
+// occupies, with East Asian Wide/Fullwidth characters, emoji
+// and grapheme clusters taken into account.
+//
+// It's a compact approximation of UAX #11 and UAX #29 which fits
+// the progress bars: the ambiguous-width characters are narrow,
+// and a cluster is a base character followed by the combining
+// marks, variation selectors, emoji modifiers and ZWJ sequences,
+// or a pair of regional indicators (a flag).
+package cellwidth
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	zwj  = '\u200d' // zero width joiner
+	vs16 = '\ufe0f' // variation selector-16, emoji presentation
+)
+
+// RuneWidth returns the cells occupied by r: 0 for the control
+// and the zero-width characters, 2 for the wide characters, or 1.
+func RuneWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case r < 0x300:
+		return 1
+	case isZeroWidth(r):
+		return 0
+	case inTable(r, wide):
+		return 2
+	}
+	return 1
+}
+
+// Next returns the byte length and the width of the first grapheme
+// cluster in s.
+func Next(s string) (size, width int) {
+	if s == "" {
+		return 0, 0
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	width = RuneWidth(r)
+	ri := isRegionalIndicator(r)
+
+	for size < len(s) {
+		next, l := utf8.DecodeRuneInString(s[size:])
+		switch {
+		case next == zwj:
+			// the joined character belongs to this cluster
+			size += l
+			if size < len(s) {
+				_, l = utf8.DecodeRuneInString(s[size:])
+				size += l
+			}
+			continue
+		case next == vs16:
+			if width == 1 {
+				width = 2
+			}
+		case ri && isRegionalIndicator(next):
+			ri = false // a flag is made of two indicators only
+			width++
+		case isZeroWidth(next):
+		default:
+			return
+		}
+		size += l
+	}
+	return
+}
+
+// String returns the cells occupied by s.
+func String(s string) (n int) {
+	for len(s) > 0 {
+		size, w := Next(s)
+		n += w
+		s = s[size:]
+	}
+	return
+}
+
+func isRegionalIndicator(r rune) bool { return r >= 0x1f1e6 && r <= 0x1f1ff }
+
+func isZeroWidth(r rune) bool {
+	switch {
+	// zero width joiner, space, non-joiner, word joiner and BOM
+	case r == zwj, r == '\u200b', r == '\u200c', r == '\u2060', r == '\ufeff':
+		return true
+	case r >= 0xfe00 && r <= 0xfe0f, r >= 0xe0100 && r <= 0xe01ef: // variation selectors
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff: // emoji modifiers (skin tones)
+		return true
+	case r >= 0x1160 && r <= 0x11ff: // hangul jamo medial vowels and final consonants
+		return true
+	case r >= 0xe0020 && r <= 0xe007f: // tags
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me)
+}
+
+func inTable(r rune, table [][2]rune) bool {
+	lo, hi := 0, len(table)
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		switch {
+		case r < table[m][0]:
+			hi = m
+		case r > table[m][1]:
+			lo = m + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// wide lists the East Asian Wide (W) and Fullwidth (F) ranges,
+// including the emoji with default emoji presentation.
+var wide = [][2]rune{
+	{0x1100, 0x115f}, {0x231a, 0x231b}, {0x2329, 0x232a}, {0x23e9, 0x23ec},
+	{0x23f0, 0x23f0}, {0x23f3, 0x23f3}, {0x25fd, 0x25fe}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267f, 0x267f}, {0x2693, 0x2693}, {0x26a1, 0x26a1},
+	{0x26aa, 0x26ab}, {0x26bd, 0x26be}, {0x26c4, 0x26c5}, {0x26ce, 0x26ce},
+	{0x26d4, 0x26d4}, {0x26ea, 0x26ea}, {0x26f2, 0x26f3}, {0x26f5, 0x26f5},
+	{0x26fa, 0x26fa}, {0x26fd, 0x26fd}, {0x2705, 0x2705}, {0x270a, 0x270b},
+	{0x2728, 0x2728}, {0x274c, 0x274c}, {0x274e, 0x274e}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27b0, 0x27b0}, {0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c}, {0x2b50, 0x2b50}, {0x2b55, 0x2b55}, {0x2e80, 0x303e},
+	{0x3041, 0x33ff}, {0x3400, 0x4dbf}, {0x4e00, 0x9fff}, {0xa000, 0xa4cf},
+	{0xa960, 0xa97f}, {0xac00, 0xd7a3}, {0xf900, 0xfaff}, {0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f}, {0xff00, 0xff60}, {0xffe0, 0xffe6},
+	{0x16fe0, 0x16fe4}, {0x17000, 0x18aff}, {0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf}, {0x1f18e, 0x1f18e}, {0x1f191, 0x1f19a},
+	{0x1f200, 0x1f202}, {0x1f210, 0x1f23b}, {0x1f240, 0x1f248}, {0x1f250, 0x1f251},
+	{0x1f260, 0x1f265}, {0x1f300, 0x1f320}, {0x1f32d, 0x1f335}, {0x1f337, 0x1f37c},
+	{0x1f37e, 0x1f393}, {0x1f3a0, 0x1f3ca}, {0x1f3cf, 0x1f3d3}, {0x1f3e0, 0x1f3f0},
+	{0x1f3f4, 0x1f3f4}, {0x1f3f8, 0x1f43e}, {0x1f440, 0x1f440}, {0x1f442, 0x1f4fc},
+	{0x1f4ff, 0x1f53d}, {0x1f54b, 0x1f54e}, {0x1f550, 0x1f567}, {0x1f57a, 0x1f57a},
+	{0x1f595, 0x1f596}, {0x1f5a4, 0x1f5a4}, {0x1f5fb, 0x1f64f}, {0x1f680, 0x1f6c5},
+	{0x1f6cc, 0x1f6cc}, {0x1f6d0, 0x1f6d2}, {0x1f6d5, 0x1f6d7}, {0x1f6dc, 0x1f6df},
+	{0x1f6eb, 0x1f6ec}, {0x1f6f4, 0x1f6fc}, {0x1f7e0, 0x1f7eb}, {0x1f7f0, 0x1f7f0},
+	{0x1f90c, 0x1f93a}, {0x1f93c, 0x1f945}, {0x1f947, 0x1f9ff}, {0x1fa70, 0x1faff},
+	{0x20000, 0x2fffd}, {0x30000, 0x3fffd},
+}
@@ -0,0 +1,106 @@
+package progressbar
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LinePrinter prints the text lines above the live progress bars
+// safely. Both MPBV2 and the MultiPB of New implement it:
+//
+//	mb.(progressbar.LinePrinter).Println("done")
+//
+// While the bars are being drawn, the lines are buffered and
+// emitted above the bars at the next frame. Otherwise they are
+// written to the output device directly.
+type LinePrinter interface {
+	Println(args ...any)
+	Printf(format string, args ...any)
+}
+
+// NewLogHandler returns a slog.Handler which formats the records
+// as slog.TextHandler does, and prints them above the live bars
+// via p.
+//
+//	mpb := progressbar.NewV2()
+//	logger := slog.New(progressbar.NewLogHandler(mpb, nil))
+//	logger.Info("downloading", "url", url)
+//
+// MPBV2.Logger() returns such a logger by default. With MPBV2 and
+// MultiPB, the records are written to stderr while the bars aren't
+// being drawn, so they never mix with the output of RenderPlain or
+// RenderJSON.
+func NewLogHandler(p LinePrinter, opts *slog.HandlerOptions) slog.Handler {
+	return slog.NewTextHandler(printerWriter{p}, opts)
+}
+
+type printerWriter struct{ p LinePrinter }
+
+func (w printerWriter) Write(data []byte) (n int, err error) {
+	line := strings.TrimSuffix(string(data), "\n")
+	if l, ok := w.p.(logPrinter); ok {
+		l.printLog(line)
+	} else {
+		w.p.Printf("%s", line)
+	}
+	return len(data), nil
+}
+
+// logPrinter is implemented by MPBV2 and mpbar, which print the log
+// records above the live bars, or to stderr otherwise.
+type logPrinter interface {
+	printLog(line string)
+}
+
+// logOut is where the log records go while the bars aren't live.
+var logOut io.Writer = os.Stderr
+
+// lineBuffer collects the lines which will be printed above the
+// live area at the next frame.
+type lineBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	live  bool // the live area is being drawn
+}
+
+// add buffers line if the live area is being drawn, or returns
+// false to tell the caller writing it directly.
+func (b *lineBuffer) add(line string) (buffered bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.live {
+		b.lines = append(b.lines, strings.TrimSuffix(line, "\n"))
+	}
+	return b.live
+}
+
+func (b *lineBuffer) take() (lines []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lines, b.lines = b.lines, nil
+	return
+}
+
+// setLive switches the buffering state. The lines not printed yet
+// are returned once the live area stopped.
+func (b *lineBuffer) setLive(live bool) (rest []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.live = live; !live {
+		rest, b.lines = b.lines, nil
+	}
+	return
+}
+
+// writeLines writes lines to w, each one ends with a newline.
+func writeLines(w io.Writer, lines ...string) {
+	for _, line := range lines {
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		_, _ = io.WriteString(w, line)
+	}
+}
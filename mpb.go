@@ -3,38 +3,92 @@ package progressbar
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"sync"
 	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/hedzr/is/term/color"
 )
 
+// NewV2 creates a MPBV2 with opts. The error of opts, such as an
+// invalid schema of WithSchema, is reported by Err, see also
+// TryNewV2.
 func NewV2(opts ...OptV2) *MPBV2 {
 	s := &MPBV2{
-		chPaint: make(chan struct{}, 8192),
-		logger:  slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{})),
+		out:       os.Stdout,
+		refresh:   defaultRefreshInterval,
+		heartbeat: defaultHeartbeat,
 	}
+	s.logger = slog.New(NewLogHandler(s, &slog.HandlerOptions{}))
 	for _, opt := range opts {
 		opt(s)
 	}
+	switch s.mode = s.mode.resolve(s.out); s.mode {
+	case RenderPlain:
+		s.plain = newPlainPrinter()
+	case RenderJSON:
+		s.json = newJSONPrinter(s.out)
+	}
+	if s.mode != RenderTTY {
+		s.tp = nil
+	} else {
+		measureTerm(s.out)
+	}
+	if s.schema != "" && s.err == nil {
+		_, s.err = compileSchema(s.schema, s.prof(), s.schemaFuncs)
+	}
 	return s
 }
 
+// TryNewV2 is like NewV2, but returns the error of opts instead of
+// keeping it for Err:
+//
+//	mpb, err := progressbar.TryNewV2(progressbar.WithSchema(schema))
+//	if err != nil {
+//		return err
+//	}
+func TryNewV2(opts ...OptV2) (*MPBV2, error) {
+	s := NewV2(opts...)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s, nil
+}
+
 type MPBV2 struct {
 	startIdx   int // for repaint groups
 	closed     int32
-	chPaint    chan struct{}
+	dirty      int32         // something changed since last frame
+	resized    int32         // terminal window was resized since last frame
+	refresh    time.Duration // the minimal interval between two frames
+	heartbeat  time.Duration // repaint at least once per heartbeat, 0 to disable
 	muPainting sync.RWMutex
 
+	out    io.Writer
 	logger *slog.Logger
+	logs   lineBuffer // the lines printed above the live bars
 	groups []*GroupV2
 
 	schema      string
+	schemaFuncs template.FuncMap // see WithSchemaFuncs
+	table       []ColumnAlign    // non-nil if WithTableLayout
+	err         error            // see Err
 	taskBarOpts []TaskBarOpt
+
+	mode  RenderMode
+	plain *plainPrinter // non-nil in RenderPlain mode
+	json  *jsonPrinter  // non-nil in RenderJSON mode
+
+	tp *terminalProgress // non-nil if WithTerminalProgress
+
+	profile *Profile           // non-nil if WithProfile
+	theme   *Theme             // non-nil if WithTheme
+	header  *template.Template // the group header of theme
 }
 
 type GroupV2 struct {
@@ -42,10 +96,12 @@ type GroupV2 struct {
 	tasks        []*TaskBar
 	done         int32
 	titlePainted int32
+	title        string // the title painted, see rowsBelow
 	muTasks      sync.RWMutex
 	block        color.RowsBlock
 	wg           sync.WaitGroup
-	dad          Repaintable // pointed to *MPBV2
+	dad          Repaintable  // pointed to *MPBV2
+	table        *tableLayout // non-nil if WithTableLayout
 }
 
 type TaskBar struct {
@@ -53,15 +109,23 @@ type TaskBar struct {
 
 	stopTime  time.Time
 	startTime time.Time
+	muTime    sync.Mutex // guards startTime and stopTime
 
 	min, max   int64
 	progress   int64
 	job        Job
 	downloader *DownloadTask
 	running    int32
-
-	dad            Repaintable // pointed to *MPBV2
-	stepper        BarT        // stepper or spinner here
+	err        error // set once the task failed
+	muErr      sync.Mutex
+
+	dad            Repaintable  // pointed to *MPBV2
+	stepper        BarT         // stepper or spinner here
+	stepperOpts    []StepperOpt // applied to each stepper picked, before its own options
+	segs           segCounter   // see StepSegment
+	rate           rateEstimator
+	data           any          // see WithTaskBarData
+	table          *tableLayout // non-nil if WithTableLayout
 	onDataPrepared OnDataPrepared
 }
 
@@ -83,6 +147,7 @@ type Logger interface {
 type TaskBarOpt func(*TaskBar)
 
 var _ Logger = (*MPBV2)(nil)
+var _ LinePrinter = (*MPBV2)(nil)
 var _ Repaintable = (*MPBV2)(nil)
 var _ MiniResizeableBar = (*TaskBar)(nil)
 
@@ -98,28 +163,166 @@ func WithSchema(schema string) OptV2 {
 	}
 }
 
+// WithSchemaFuncs adds funcs to the functions of the schemas of all
+// tasks, they take precedence over the built-in ones:
+//
+//	mpb := progressbar.NewV2(
+//		progressbar.WithSchemaFuncs(template.FuncMap{"upper": strings.ToUpper}),
+//		progressbar.WithSchema(`{{.Title|upper}} {{.Bar}} {{.Percent|right 7}}`),
+//	)
+//
+// See WithStepperSchemaFuncs for the built-in functions.
+func WithSchemaFuncs(funcs template.FuncMap) OptV2 {
+	return func(m *MPBV2) {
+		m.schemaFuncs = mergeFuncs(m.schemaFuncs, funcs)
+	}
+}
+
+// WithTableLayout aligns the columns of the tasks in each group like
+// a table: the title, percent, sizes, speed and elapsed time are
+// padded to the widest one among the visible tasks, so the bars line
+// up too. The title is aligned left and the others right by default:
+//
+//	mpb := progressbar.NewV2(progressbar.WithTableLayout(
+//		progressbar.ColumnAlign{Column: progressbar.ColumnTitle, Align: progressbar.AlignRight},
+//	))
+func WithTableLayout(aligns ...ColumnAlign) OptV2 {
+	return func(m *MPBV2) {
+		m.table = append([]ColumnAlign{}, aligns...)
+	}
+}
+
 func WithTaskOpts(opts ...TaskBarOpt) OptV2 {
 	return func(m *MPBV2) {
 		m.taskBarOpts = opts
 	}
 }
 
+// WithOutput specifies the destination of the bars. Default is
+// os.Stdout.
+//
+// For example, route the progress to os.Stderr to keep stdout
+// clean for the piped data, or into a bytes.Buffer in testing.
+func WithOutput(out io.Writer) OptV2 {
+	return func(m *MPBV2) {
+		if out != nil {
+			m.out = out
+		}
+	}
+}
+
+// WithRefreshInterval specifies the minimal interval between two
+// frames. Default is 100ms.
+//
+// Writing to a bar just marks it dirty, the bars will be repainted
+// at most once per interval. It avoids burning CPU while a fast
+// download writes thousands of times per second.
+func WithRefreshInterval(d time.Duration) OptV2 {
+	return func(m *MPBV2) {
+		if d > 0 {
+			m.refresh = d
+		}
+	}
+}
+
+// WithHeartbeat specifies how often the bars are repainted even if
+// no progress was made, so the spinners keep turning and the
+// elapsed time keeps counting. Default is 100ms, 0 disables it.
+//
+// It takes effect in RenderTTY mode only.
+func WithHeartbeat(d time.Duration) OptV2 {
+	return func(m *MPBV2) {
+		m.heartbeat = max(d, 0)
+	}
+}
+
+// WithRenderMode specifies how the bars are drawn.
+//
+// Default is RenderAuto, which draws animated bars on a terminal
+// and prints plain text lines if stdout is redirected or piped.
+func WithRenderMode(mode RenderMode) OptV2 {
+	return func(m *MPBV2) {
+		m.mode = mode
+	}
+}
+
+// WithTerminalProgress reports the overall progress of all bars to
+// the terminal on each frame: the tab/taskbar progress indicator
+// via the sequence OSC 9;4, and the percent in the window title
+// via OSC 0 if title isn't empty, such as "downloading 45%".
+//
+// They are cleared when Run returns or Close is called. It takes
+// effect in RenderTTY mode only.
+func WithTerminalProgress(title string) OptV2 {
+	return func(m *MPBV2) {
+		m.tp = newTerminalProgress(title)
+	}
+}
+
+// WithProfile specifies the color and glyph capabilities of the
+// terminal for all bars, instead of the detected one (see
+// DetectProfile).
+//
+// For example, ProfileASCII draws the bars without colors and
+// with the ASCII glyphs only.
+func WithProfile(p Profile) OptV2 {
+	return func(m *MPBV2) {
+		m.profile = &p
+	}
+}
+
+// WithTheme applies a theme, such as ThemeCargo, to all bars and
+// group headers. An invalid GroupHeader is reported by Err.
+//
+// The theme is applied to the stepper picked by each task, the
+// stepper 0 by default, before the options of WithSchema,
+// WithTaskOpts and AddBar, so they take precedence over it.
+func WithTheme(t Theme) OptV2 {
+	return func(m *MPBV2) {
+		var err error
+		m.theme = &t
+		if m.header, err = t.header(); err != nil && m.err == nil {
+			m.err = err
+		}
+	}
+}
+
 //
 // ------------------------------ TASK BAR OPTS
 //
 
 func WithTaskBarStepper(stepperIndex int, opts ...StepperOpt) TaskBarOpt {
 	return func(tb *TaskBar) {
-		if s, ok := steppers[stepperIndex]; ok {
-			tb.stepper = s.init(opts...)
+		if s, ok := stepperAt(stepperIndex); ok {
+			tb.stepper = s.clone(withDefaults(tb.stepperOpts, opts)...)
 		}
 	}
 }
 
 func WithTaskBarSpinner(spinnersIndex int, opts ...StepperOpt) TaskBarOpt {
 	return func(tb *TaskBar) {
-		if s, ok := spinners[spinnersIndex]; ok {
-			tb.stepper = s.init(opts...)
+		if s, ok := spinnerAt(spinnersIndex); ok {
+			tb.stepper = s.clone(withDefaults(tb.stepperOpts, opts)...)
+		}
+	}
+}
+
+// WithTaskBarStepperNamed picks a stepper registered by
+// RegisterStepper.
+func WithTaskBarStepperNamed(name string, opts ...StepperOpt) TaskBarOpt {
+	return func(tb *TaskBar) {
+		if s, ok := stepperNamed(name); ok {
+			tb.stepper = s.clone(withDefaults(tb.stepperOpts, opts)...)
+		}
+	}
+}
+
+// WithTaskBarSpinnerNamed picks a spinner registered by
+// RegisterSpinner.
+func WithTaskBarSpinnerNamed(name string, opts ...StepperOpt) TaskBarOpt {
+	return func(tb *TaskBar) {
+		if s, ok := spinnerNamed(name); ok {
+			tb.stepper = s.clone(withDefaults(tb.stepperOpts, opts)...)
 		}
 	}
 }
@@ -169,59 +372,195 @@ func WithTaskBarExtraTailSpaces(howMany int) TaskBarOpt {
 	}
 }
 
+// WithTaskBarTitleMaxWidth limits the title to w terminal columns,
+// a longer title will be cut with an ellipsis. Default is 0 (no
+// limit).
+func WithTaskBarTitleMaxWidth(w int) TaskBarOpt {
+	return func(tb *TaskBar) {
+		tb.stepper.SetTitleMaxWidth(w)
+	}
+}
+
+// WithTaskBarPartials specifies the glyphs of a cell from the
+// least to the fully filled, see WithStepperPartials.
+func WithTaskBarPartials(glyphs ...string) TaskBarOpt {
+	return func(tb *TaskBar) {
+		tb.stepper.SetPartials(glyphs...)
+	}
+}
+
+// WithTaskBarData attaches your data to the task, as the Data of
+// SchemaData, such as {{.Data.Host}}.
+func WithTaskBarData(data any) TaskBarOpt {
+	return func(tb *TaskBar) {
+		tb.data = data
+	}
+}
+
+// WithTaskBarOnDataPrepared specifies a callback to observe or
+// change the SchemaData of each frame before it's rendered.
+func WithTaskBarOnDataPrepared(cb OnDataPrepared) TaskBarOpt {
+	return func(tb *TaskBar) {
+		tb.onDataPrepared = cb
+	}
+}
+
+// WithTaskBarUnit specifies how Current, Total and the speeds are
+// formatted, see WithStepperUnit.
+func WithTaskBarUnit(u Unit) TaskBarOpt {
+	return func(tb *TaskBar) {
+		tb.stepper.SetUnit(u)
+	}
+}
+
+// WithTaskBarSchemaFuncs adds funcs to the functions of the
+// schema, see WithStepperSchemaFuncs.
+func WithTaskBarSchemaFuncs(funcs template.FuncMap) TaskBarOpt {
+	return func(tb *TaskBar) {
+		tb.stepper.SetSchemaFuncs(funcs)
+	}
+}
+
+// WithTaskBarSegments specifies the segments of a stacked bar, see
+// WithStepperSegments and StepSegment.
+func WithTaskBarSegments(segs ...Segment) TaskBarOpt {
+	return func(tb *TaskBar) {
+		tb.stepper.SetSegments(segs...)
+	}
+}
+
+// WithTaskBarProfile specifies the color and glyph capabilities
+// of the terminal, instead of the detected one (see DetectProfile).
+func WithTaskBarProfile(p Profile) TaskBarOpt {
+	return func(tb *TaskBar) {
+		tb.stepper.SetProfile(p)
+	}
+}
+
+// WithTaskBarTheme applies the glyphs, colors and schema of a
+// theme, such as ThemeCargo. The stepper 0 is used if no stepper
+// was picked.
+func WithTaskBarTheme(t Theme) TaskBarOpt {
+	return func(tb *TaskBar) {
+		if tb.stepper == nil {
+			WithTaskBarStepper(0)(tb)
+		}
+		tb.stepper.SetTheme(t)
+	}
+}
+
 //
 // ------------------------------ MPBV2
 //
 
 func (s *MPBV2) Close() {
-	if atomic.CompareAndSwapInt32(&s.closed, 0, 1) {
-		close(s.chPaint)
-		s.chPaint = nil
+	atomic.StoreInt32(&s.closed, 1)
+	if s.tp != nil {
+		s.tp.clear(s.out)
 	}
 }
 
 func (s *MPBV2) AddDownloadingBar(group, task string, d *DownloadTask, opts ...TaskBarOpt) (err error) {
+	return s.addTo(group, task, func(grp *GroupV2) error {
+		return grp.AddDownloader(s, task, d, s.taskOpts(opts)...)
+	})
+}
+
+// AddBar adds a task running job to the group, which is created if
+// not found. It returns the error of Err, or the error of an invalid
+// schema of the task (see CompileSchema), and the task isn't added
+// in these cases.
+func (s *MPBV2) AddBar(group, task string, min, max int64, job Job, opts ...TaskBarOpt) (err error) {
+	return s.addTo(group, task, func(grp *GroupV2) error {
+		return grp.AddTask(s, task, min, max, job, s.taskOpts(opts)...)
+	})
+}
+
+// addTo adds a task to the group by add. The group is created if not
+// found, and kept only if add succeeds.
+func (s *MPBV2) addTo(group, task string, add func(grp *GroupV2) error) (err error) {
 	s.muPainting.Lock()
 	defer s.muPainting.Unlock()
 
-	var grp *GroupV2
-	if grp, err = s.findGroup(group); err != nil {
-		grp = &GroupV2{Name: group, dad: s}
-		grp.block = color.NewRowsBlock()
+	if s.err != nil {
+		return s.err
+	}
+
+	grp, err := s.findGroup(group)
+	created := err != nil
+	if created {
+		grp = s.newGroup(group)
+	}
+	if err = add(grp); err != nil {
+		return
+	}
+	if created {
 		s.groups = append(s.groups, grp)
-		err = nil
 	}
+	s.added(grp, task)
+	return
+}
 
-	var to []TaskBarOpt
+// Err returns the error of the options of NewV2, such as an invalid
+// schema of WithSchema (see CompileSchema) or an invalid group header
+// of WithTheme, or nil. AddBar and
+// AddDownloadingBar return it too.
+func (s *MPBV2) Err() error {
+	return s.err
+}
+
+// taskOpts returns the options of a task: the theme, schema and
+// schema functions of MPBV2, WithTaskOpts, and then opts. They are kept
+// as the defaults of the stepper, so they survive a task picking
+// another stepper or spinner.
+func (s *MPBV2) taskOpts(opts []TaskBarOpt) (to []TaskBarOpt) {
+	var defaults []StepperOpt
+	if s.theme != nil {
+		defaults = append(defaults, WithStepperTheme(*s.theme))
+	}
 	if s.schema != "" {
-		to = append(to, WithTaskBarStepper(0, WithStepperSchema(s.schema)))
+		defaults = append(defaults, WithStepperSchema(s.schema))
+	}
+	if s.schemaFuncs != nil {
+		defaults = append(defaults, WithStepperSchemaFuncs(s.schemaFuncs))
+	}
+	if len(defaults) > 0 {
+		to = append(to, func(tb *TaskBar) { tb.stepperOpts = defaults }, WithTaskBarStepper(0))
 	}
 	to = append(to, s.taskBarOpts...)
 	to = append(to, opts...)
-	err = grp.AddDownloader(s, task, d, to...)
 	return
 }
 
-func (s *MPBV2) AddBar(group, task string, min, max int64, job Job, opts ...TaskBarOpt) (err error) {
-	s.muPainting.Lock()
-	defer s.muPainting.Unlock()
+// prof returns the profile specified by WithProfile, or the
+// detected one.
+func (s *MPBV2) prof() Profile {
+	if s.profile != nil {
+		return *s.profile
+	}
+	return DetectProfile()
+}
 
-	var grp *GroupV2
-	if grp, err = s.findGroup(group); err != nil {
-		grp = &GroupV2{Name: group, dad: s}
-		grp.block = color.NewRowsBlock()
-		s.groups = append(s.groups, grp)
-		err = nil
+// added emits the added event in RenderJSON mode.
+func (s *MPBV2) added(grp *GroupV2, task string) {
+	if s.json != nil {
+		s.json.added(grp.Name, grp.TaskByName(task))
 	}
+}
 
-	var to []TaskBarOpt
-	if s.schema != "" {
-		to = append(to, WithTaskBarStepper(0, WithStepperSchema(s.schema)))
+func (s *MPBV2) newGroup(group string) *GroupV2 {
+	grp := &GroupV2{Name: group, dad: s}
+	if s.table != nil {
+		grp.table = newTableLayout(s.table)
 	}
-	to = append(to, s.taskBarOpts...)
-	to = append(to, opts...)
-	err = grp.AddTask(s, task, min, max, job, to...)
-	return
+	grp.block = newRowsBlock(s.out)
+	return grp
+}
+
+func newRowsBlock(out io.Writer) color.RowsBlock {
+	block := color.NewRowsBlock()
+	block.WithWriter(colorWriter(out))
+	return block
 }
 
 func (s *MPBV2) findGroup(group string) (grp *GroupV2, err error) {
@@ -233,8 +572,38 @@ func (s *MPBV2) findGroup(group string) (grp *GroupV2, err error) {
 	return nil, errNotFound
 }
 
+// Logger returns a logger which prints the records above the live
+// bars safely, see NewLogHandler.
 func (s *MPBV2) Logger() *slog.Logger { return s.logger }
 
+// Println prints a line above the live bars. It's safe to be called
+// while Run is drawing the bars.
+func (s *MPBV2) Println(args ...any) { s.printLine(fmt.Sprintln(args...)) }
+
+// Printf prints a line above the live bars. It's safe to be called
+// while Run is drawing the bars.
+func (s *MPBV2) Printf(format string, args ...any) { s.printLine(fmt.Sprintf(format, args...)) }
+
+func (s *MPBV2) printLine(line string) {
+	if s.json != nil {
+		s.json.log(line)
+		return
+	}
+	if s.logs.add(line) {
+		s.Repaint()
+		return
+	}
+	writeLines(s.out, line)
+}
+
+func (s *MPBV2) printLog(line string) {
+	if s.logs.add(line) {
+		s.Repaint()
+		return
+	}
+	writeLines(logOut, line)
+}
+
 func (s *MPBV2) GroupByIndex(index int) *GroupV2 {
 	s.muPainting.RLock()
 	defer s.muPainting.RUnlock()
@@ -259,23 +628,46 @@ func (s *MPBV2) GroupByName(group string) *GroupV2 {
 // 	//
 // }
 
-func (s *MPBV2) Run(ctx context.Context) {
+// Run runs the tasks and draws the bars until all groups are done or
+// ctx is done. The tasks unfinished then are marked cancelled. It
+// returns the errors of the failed and cancelled tasks joined, each
+// wrapped with the names of its group and task, or nil if all tasks
+// succeeded.
+func (s *MPBV2) Run(ctx context.Context) (err error) {
 	exitCh := make(chan struct{}, 8)
 	pc := newPaintCtx(s)
 
-	color.Hide()
+	if s.mode == RenderTTY {
+		_, _ = io.WriteString(s.out, cursorHide)
+		defer addResizeListener(s.onResized)()
+		s.logs.setLive(true)
+	}
 
 	defer func() {
+		s.cancel(ctx)
 		pc.full = true
 		s.repaint(pc)
 		s.stop(ctx, pc)
 		close(exitCh)
-		color.Show()
+		writeLines(s.out, s.logs.setLive(false)...)
+		if s.tp != nil {
+			s.tp.clear(s.out)
+		}
+		if s.mode == RenderTTY {
+			_, _ = io.WriteString(s.out, cursorShow)
+		}
+		err = s.errs()
 	}()
 
 	// collect downloading tasks and initialize them
 	downloaders := s.start(ctx, pc)
 
+	ticker := time.NewTicker(s.refresh)
+	defer ticker.Stop()
+
+	beat, stopBeat := heartbeat(s.mode, s.heartbeat)
+	defer stopBeat()
+
 	var gi int
 	var grp *GroupV2
 	for {
@@ -283,8 +675,10 @@ func (s *MPBV2) Run(ctx context.Context) {
 		case <-ctx.Done():
 			exitCh <- struct{}{}
 			return
-		case <-s.chPaint:
-			s.repaint(pc)
+		case <-ticker.C:
+			s.repaintIfDirty(pc)
+		case <-beat:
+			s.Repaint()
 		default:
 			if grp, gi = s.chooseGroup(gi); grp != nil {
 				if downloaders != nil {
@@ -298,9 +692,10 @@ func (s *MPBV2) Run(ctx context.Context) {
 						for _, tsk := range tsks {
 							if _, _, done := tsk.Done(); !done {
 								if atomic.CompareAndSwapInt32(&tsk.running, 0, 1) {
+									tsk.startNow()
 									go func() {
 										stop := tsk.downloader.doWorker(tsk, exitCh)
-										if _, _, done = tsk.Done(); done || stop {
+										if _, _, done = tsk.Done(); done || stop || tsk.Err() != nil {
 											atomic.AddInt32(&grp.done, 1)
 										}
 									}()
@@ -310,15 +705,26 @@ func (s *MPBV2) Run(ctx context.Context) {
 						_ = grp
 					}
 				}
-				if allDone := grp.runJobs(ctx, s); allDone {
+				if allDone, ran := grp.runJobs(ctx, s); allDone {
 					pc.full = true
-					// try cleanup stacked signals in chPaint
+					// force the final frame of this group
+					atomic.StoreInt32(&s.dirty, 0)
 					var ignored = true
 					for ignored {
-						emptyIt(s.chPaint)
 						ignored = grp.repaint(pc)
 					}
 					grp.block.Bottom()
+				} else if !ran {
+					// nothing to do but waiting for the downloaders
+					select {
+					case <-ctx.Done():
+						exitCh <- struct{}{}
+						return
+					case <-ticker.C:
+						s.repaintIfDirty(pc)
+					case <-beat:
+						s.Repaint()
+					}
 				}
 			} else {
 				return
@@ -327,6 +733,41 @@ func (s *MPBV2) Run(ctx context.Context) {
 	}
 }
 
+// cancel marks the tasks unfinished cancelled if ctx is done.
+func (s *MPBV2) cancel(ctx context.Context) {
+	if ctx.Err() == nil {
+		return
+	}
+	s.muPainting.RLock()
+	defer s.muPainting.RUnlock()
+	for _, grp := range s.groups {
+		grp.muTasks.Lock()
+		for _, tsk := range grp.tasks {
+			if _, _, done := tsk.Done(); !done {
+				tsk.fail(ctx.Err())
+			}
+		}
+		grp.muTasks.Unlock()
+	}
+}
+
+// errs returns the errors of the tasks joined, see Run.
+func (s *MPBV2) errs() error {
+	s.muPainting.RLock()
+	defer s.muPainting.RUnlock()
+	var errs []error
+	for _, grp := range s.groups {
+		grp.muTasks.Lock()
+		for _, tsk := range grp.tasks {
+			if err := tsk.Err(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", grp.Name, tsk.Name, err))
+			}
+		}
+		grp.muTasks.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
 func (s *MPBV2) stop(ctx context.Context, pc *paintCtx) {
 	s.muPainting.RLock()
 	defer s.muPainting.RUnlock()
@@ -361,6 +802,9 @@ func (s *MPBV2) start(ctx context.Context, pc *paintCtx) (downloaders map[*Group
 				WithTaskBarStepper(0)(tsk)
 				tsk.startNow()
 			}
+			if s.profile != nil {
+				tsk.stepper.SetProfile(*s.profile)
+			}
 		}
 		grp.muTasks.Unlock()
 	}
@@ -389,8 +833,23 @@ func (s *MPBV2) chooseGroup(gi int) (grp *GroupV2, idx int) {
 	return
 }
 
+// onResized erases the lines wrapped by the terminal in the next
+// frame, see GroupV2.repaint.
+func (s *MPBV2) onResized() {
+	atomic.StoreInt32(&s.resized, 1)
+	s.Repaint()
+}
+
+// Repaint marks the bars dirty, they will be repainted at the
+// next frame. See also WithRefreshInterval.
 func (s *MPBV2) Repaint() {
-	s.chPaint <- struct{}{}
+	atomic.StoreInt32(&s.dirty, 1)
+}
+
+func (s *MPBV2) repaintIfDirty(pc *paintCtx) {
+	if atomic.CompareAndSwapInt32(&s.dirty, 1, 0) {
+		s.repaint(pc)
+	}
 }
 
 // func (s *MPBV2) RepaintNow() {
@@ -402,10 +861,9 @@ func (s *MPBV2) repaint(pc *paintCtx) {
 		defer s.muPainting.RUnlock()
 		s.repaintImpl(pc)
 	} else if pc.full {
-		// try cleanup stacked signals in chPaint
+		// waiting for the final frame
 		var ignored = true
 		for ignored {
-			emptyIt(s.chPaint)
 			time.Sleep(time.Millisecond)
 			if s.muPainting.TryRLock() {
 				ignored = false
@@ -419,6 +877,14 @@ func (s *MPBV2) repaint(pc *paintCtx) {
 }
 
 func (s *MPBV2) repaintImpl(pc *paintCtx) {
+	if s.json != nil {
+		// no screen space limited, all groups are reported
+		for _, grp := range s.groups {
+			grp.repaint(pc)
+		}
+		return
+	}
+
 	var grp *GroupV2
 	if grp, s.startIdx = s.chooseGroup(s.startIdx); grp != nil {
 		if s.startIdx > 0 {
@@ -428,13 +894,39 @@ func (s *MPBV2) repaintImpl(pc *paintCtx) {
 		}
 		grp.repaint(pc)
 	}
+	if s.tp != nil {
+		var tasks []*TaskBar
+		for _, grp := range s.groups {
+			grp.muTasks.RLock()
+			tasks = append(tasks, grp.tasks...)
+			grp.muTasks.RUnlock()
+		}
+		reportProgress(s.out, s.tp, tasks)
+	}
 }
 
 // func (s *MPBV2) onDraw() {
 // 	//
 // }
 
+const (
+	defaultRefreshInterval = 100 * time.Millisecond
+	defaultHeartbeat       = 100 * time.Millisecond
+)
+
+// heartbeat returns the channel ticking every d in RenderTTY mode,
+// or a nil channel which never fires. Call stop once done.
+func heartbeat(mode RenderMode, d time.Duration) (beat <-chan time.Time, stop func()) {
+	if mode != RenderTTY || d <= 0 {
+		return nil, func() {}
+	}
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
 var (
 	errNotFound    = errors.New("not-found")
 	errTaskExisted = errors.New("task-existed")
+
+	errInvalidDownload = errors.New("nil request, response or file")
 )
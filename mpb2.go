@@ -4,11 +4,11 @@
 package progressbar
 
 import (
+	"io"
 	"os"
+	"strings"
 	"sync/atomic"
 	"time"
-
-	"github.com/hedzr/is/term/color"
 )
 
 type GroupedPB interface {
@@ -33,14 +33,17 @@ func multiBar2(opts ...MOpt) *mpbar2 {
 			out:       os.Stdout,
 			sigRedraw: make(chan struct{}, 16),
 			sigExit:   make(chan struct{}, 16),
+			heartbeat: defaultHeartbeat,
 		},
 	}
 
 	for _, opt := range opts {
 		opt(mpb2.mpbar)
 	}
+	mpb2.resolveMode()
+	mpb2.unwatch = addResizeListener(mpb2.onResized(mpb2.Redraw))
 
-	go mpb2.run()
+	go mpb2.run(mpb2.sigRedraw, mpb2.sigExit)
 	return mpb2
 }
 
@@ -66,17 +69,29 @@ func (bars *barsGroup) Close() {
 }
 
 func (bars *barsGroup) Add(mpb *mpbar2, maxBytes int64, title string, opts ...Opt) (index int) {
-	pb := defaultBytes(mpb, maxBytes, title, opts...).(*pbar) //nolint:errcheck //the call is always ok
+	pb := defaultBytes(mpb, maxBytes, title, mpb.barOpts(opts)...).(*pbar) //nolint:errcheck //the call is always ok
 	pb.stepper.SetIndentChars(indentChars)
+	mpb.applyProfile(pb)
 
 	mpb.rw.Lock()
 	bars.bars = append(bars.bars, pb)
 	mpb.rw.Unlock()
+	if mpb.json != nil {
+		mpb.json.added(bars.title, pb)
+	}
 	return len(bars.bars) - 1
 }
 
 func (mpb *mpbar2) Close() {
 	if atomic.CompareAndSwapInt32(&mpb.closed, 0, 1) {
+		if mpb.unwatch != nil {
+			mpb.unwatch()
+		}
+		writeLines(mpb.out, mpb.logs.setLive(false)...)
+		if mpb.tp != nil {
+			mpb.tp.clear(mpb.out)
+		}
+
 		// waiting for Redraw() completed.
 		time.Sleep(200 * time.Millisecond)
 
@@ -158,12 +173,16 @@ func (mpb *mpbar2) AddToGroup(group string, maxBytes int64, title string, opts .
 }
 
 func (mpb *mpbar2) Add(maxBytes int64, title string, opts ...Opt) (index int) {
-	pb := defaultBytes(mpb, maxBytes, title, opts...).(*pbar) //nolint:errcheck //the call is always ok
+	pb := defaultBytes(mpb, maxBytes, title, mpb.barOpts(opts)...).(*pbar) //nolint:errcheck //the call is always ok
 	pb.stepper.SetIndentChars(indentChars)
+	mpb.applyProfile(pb)
 
 	mpb.rw.Lock()
 	mpb.bars = append(mpb.bars, pb)
 	mpb.rw.Unlock()
+	if mpb.json != nil {
+		mpb.json.added("", pb)
+	}
 	return len(mpb.bars) - 1
 }
 
@@ -197,23 +216,46 @@ func (mpb *mpbar2) Remove(index int) {
 	}
 }
 
-func (mpb *mpbar2) run() {
-	// // ticker := time.NewTicker(time.Millisecond * 50)
-	// ticker := time.NewTicker(time.Second * 1)
-	// defer ticker.Stop()
+// run redraws the bars on signals, and on each heartbeat while
+// some bars are running. The channels are passed in since Close
+// resets the fields.
+func (mpb *mpbar2) run(sigRedraw, sigExit <-chan struct{}) {
+	beat, stop := heartbeat(mpb.mode, mpb.heartbeat)
+	defer stop()
 
 	for {
 		select {
-		// case <-ticker.C:
-		// 	mpb.redrawNow()
-		case <-mpb.sigRedraw:
+		case <-beat:
+			if mpb.running() {
+				mpb.redrawNow()
+			}
+		case _, ok := <-sigRedraw:
+			if !ok {
+				return
+			}
 			mpb.redrawNow()
-		case <-mpb.sigExit:
+		case <-sigExit:
 			return
 		}
 	}
 }
 
+// running tells whether some bars, grouped or not, are still in
+// progress.
+func (mpb *mpbar2) running() bool {
+	mpb.rw.RLock()
+	defer mpb.rw.RUnlock()
+	if anyRunning(mpb.bars) {
+		return true
+	}
+	for _, g := range mpb.gb {
+		if anyRunning(g.bars) {
+			return true
+		}
+	}
+	return false
+}
+
 func (mpb *mpbar2) outFlush() {
 	if ss, ok := mpb.out.(interface{ Sync() error }); ok {
 		_ = ss.Sync()
@@ -235,93 +277,179 @@ func (mpb *mpbar2) redrawNow() {
 		return
 	}
 
-	if len(mpb.gb) > 0 {
-		totalRows := 0
-		for _, gv := range mpb.gb {
-			totalRows++
-			totalRows += len(gv.bars)
-		}
+	sampleRates(mpb.bars)
+	for _, gv := range mpb.gb {
+		sampleRates(gv.bars)
+	}
+	if mpb.plain != nil {
+		mpb.printPlain()
+		return
+	}
+	if mpb.json != nil {
+		mpb.printJSON()
+		return
+	}
 
+	if len(mpb.gb) == 0 {
+		if mpb.lines > len(mpb.bars) {
+			mpb.lines = len(mpb.bars)
+		}
 		var first = atomic.CompareAndSwapInt32(&mpb.dirtyFlag, 0, 1)
 		if !first {
-			color.Left(1000)
-			color.Up(totalRows - mpb.lines)
+			mpb.moveUp(mpb.painted)
 		}
+		mpb.printLogs()
 
-		shouldBeDone, doneAll, rows := 0, 0, 0
-		for _, gv := range mpb.gb {
-			// if rows >= mpb.lines {
-			// _, _ = mpb.out.Write([]byte(fmt.Sprintf("%-30s (%d items) %d/%d", gv.title, len(gv.bars), rows, totalRows)))
-			_, _ = mpb.out.Write([]byte(gv.title))
-			_, _ = mpb.out.Write([]byte("\n"))
-			rows++
-
-			done := true
-			for _, pb := range gv.bars {
-				str := pb.String()
-				_, _ = mpb.out.Write([]byte(str))
-				_, _ = mpb.out.Write([]byte("\n"))
-				if pb.completed {
-					rows++
-				} else {
-					done = false
-					rows++
-				}
-			}
-
-			if done {
-				doneAll++
-				// if atomic.CompareAndSwapInt32(&mpb.dirtyFlag, 1, 0) {
-				// 	mpb.lines = len(gv.bars)
-				// }
-			}
-			shouldBeDone++
+		done := mpb.drawBars(mpb.bars[mpb.lines:], maxLiveRows())
+		if mpb.tp != nil {
+			reportProgress(mpb.out, mpb.tp, mpb.bars)
 		}
-		if doneAll >= shouldBeDone {
+		if done {
+			// mpb.out.Flush()
+			if atomic.CompareAndSwapInt32(&mpb.dirtyFlag, 1, 0) {
+				mpb.lines, mpb.painted = len(mpb.bars), 0
+			}
 			if mpb.onDone != nil {
 				cb := mpb.onDone
 				mpb.onDone = nil
-				mpb.outFlush()
+				// mpb.sigRedraw <- struct{}{}
 				cb(mpb)
 			}
 		}
-	} else {
-		var done = true
-		var cnt = 0
-		var first = atomic.CompareAndSwapInt32(&mpb.dirtyFlag, 0, 1)
-		if !first {
-			color.Left(1000)
-			color.Up(len(mpb.bars) - mpb.lines)
+		return
+	}
+
+	var first = atomic.CompareAndSwapInt32(&mpb.dirtyFlag, 0, 1)
+	if !first {
+		mpb.moveUp(mpb.painted)
+	}
+	mpb.printLogs()
+
+	done := mpb.drawGroups(maxLiveRows())
+	if mpb.tp != nil {
+		groups := [][]*pbar{mpb.bars}
+		for _, gv := range mpb.gb {
+			groups = append(groups, gv.bars)
 		}
+		reportProgress(mpb.out, mpb.tp, groups...)
+	}
+	if done {
+		if mpb.onDone != nil {
+			cb := mpb.onDone
+			mpb.onDone = nil
+			mpb.outFlush()
+			cb(mpb)
+		}
+	}
+}
 
-		for i, pb := range mpb.bars {
-			if i >= mpb.lines {
-				str := pb.String()
-				_, _ = mpb.out.Write([]byte(str))
-				_, _ = mpb.out.Write([]byte("\n"))
-				// _, _ = fmt.Fprintf(mpb.out, "%s%s\n", indentChars, str)
-				if !pb.completed {
-					done = false
-					cnt++
-				}
-			}
+// drawGroups draws the groups within maxRows lines. If the bars
+// cannot be shown entirely, the group titles are kept and the
+// bars are picked by viewport across all groups.
+func (mpb *mpbar2) drawGroups(maxRows int) (done bool) {
+	var all []*pbar
+	for _, gv := range mpb.gb {
+		all = append(all, gv.bars...)
+	}
+
+	titles := make([]string, len(mpb.gb))
+	for i, gv := range mpb.gb {
+		titles[i] = groupTitle(mpb.header, mpb.prof(), gv.title, len(gv.bars))
+		if cols, _ := termSize(); cols > 0 {
+			titles[i] = ellipsize(titles[i], cols-1)
+		}
+	}
+
+	var shown map[*pbar]bool
+	var summary string
+	visible := all
+	if rows := rowsBelow(maxRows, titles...); rows > 0 && len(all) > rows {
+		visible, summary = viewport(all, rows)
+		shown = make(map[*pbar]bool, len(visible))
+		for _, pb := range visible {
+			shown[pb] = true
 		}
+	}
+	measure(mpb.table, visible)
 
-		// _, _ = fmt.Fprintf(tui, "%v tasks activate [%v, %v, %v lines]\n", cnt, width, tui.Height(), len(mpb.bars)+1)
-		// _ = tui.FlushN(len(mpb.bars) + 1)
+	done, rows := true, 0
+	for i, gv := range mpb.gb {
+		_, _ = mpb.out.Write([]byte(titles[i] + "\n"))
+		rows += strings.Count(titles[i], "\n") + 1
 
-		if done {
-			// mpb.out.Flush()
-			if atomic.CompareAndSwapInt32(&mpb.dirtyFlag, 1, 0) {
-				mpb.lines = len(mpb.bars)
+		for _, pb := range gv.bars {
+			if !pb.finished() {
+				done = false
 			}
-			if mpb.onDone != nil {
-				cb := mpb.onDone
-				mpb.onDone = nil
-				// mpb.sigRedraw <- struct{}{}
-				cb(mpb)
+			if shown != nil && !shown[pb] {
+				continue
+			}
+			_, _ = mpb.out.Write([]byte(pb.String()))
+			_, _ = mpb.out.Write([]byte("\n"))
+			rows++
+		}
+	}
+	if summary != "" {
+		_, _ = io.WriteString(mpb.out, summary+"\n")
+		rows++
+	}
+	mpb.painted = rows
+	return
+}
+
+// printPlain prints the grouped bars as plain text lines, see
+// RenderPlain.
+func (mpb *mpbar2) printPlain() {
+	var done = true
+	for _, gv := range mpb.gb {
+		mpb.plain.header(mpb.out, gv.title)
+		for _, pb := range gv.bars {
+			mpb.plain.print(mpb.out, pb)
+			if !pb.finished() {
+				done = false
+			}
+		}
+	}
+	for _, pb := range mpb.bars {
+		mpb.plain.print(mpb.out, pb)
+		if !pb.finished() {
+			done = false
+		}
+	}
+	if done && mpb.onDone != nil {
+		cb := mpb.onDone
+		mpb.onDone = nil
+		mpb.outFlush()
+		cb(mpb)
+	}
+}
+
+// printJSON emits the events of the grouped bars, see RenderJSON.
+func (mpb *mpbar2) printJSON() {
+	var done = true
+	for _, gv := range mpb.gb {
+		groupDone := true
+		for _, pb := range gv.bars {
+			if !mpb.json.update(gv.title, pb) {
+				groupDone = false
 			}
 		}
+		if groupDone {
+			mpb.json.groupDone(gv.title)
+		} else {
+			done = false
+		}
+	}
+	for _, pb := range mpb.bars {
+		if !mpb.json.update("", pb) {
+			done = false
+		}
+	}
+	if done && mpb.onDone != nil {
+		cb := mpb.onDone
+		mpb.onDone = nil
+		mpb.outFlush()
+		cb(mpb)
 	}
 }
 
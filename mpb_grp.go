@@ -2,6 +2,7 @@ package progressbar
 
 import (
 	"context"
+	"io"
 	"strings"
 	"sync/atomic"
 )
@@ -14,9 +15,6 @@ func (s *GroupV2) AddDownloader(dad Repaintable, task string, d *DownloadTask, o
 		d.logger = l.Logger()
 	}
 
-	d.wg = &s.wg
-	s.wg.Add(1)
-
 	var tsk *TaskBar
 	if tsk, err = s.findTask(task); err != nil {
 		tsk = &TaskBar{Name: task, downloader: d}
@@ -24,6 +22,12 @@ func (s *GroupV2) AddDownloader(dad Repaintable, task string, d *DownloadTask, o
 		for _, opt := range opts {
 			opt(tsk)
 		}
+		if err = compileBar(tsk.stepper); err != nil {
+			return
+		}
+		tsk.table = s.table
+		d.wg = &s.wg
+		s.wg.Add(1)
 		s.tasks = append(s.tasks, tsk)
 		return nil
 	}
@@ -41,6 +45,10 @@ func (s *GroupV2) AddTask(dad Repaintable, task string, min, max int64, job Job,
 		for _, opt := range opts {
 			opt(tsk)
 		}
+		if err = compileBar(tsk.stepper); err != nil {
+			return
+		}
+		tsk.table = s.table
 		s.tasks = append(s.tasks, tsk)
 		return nil
 	}
@@ -77,7 +85,9 @@ func (s *GroupV2) allDone() bool {
 	return int(atomic.LoadInt32(&s.done)) >= len(s.tasks)
 }
 
-func (s *GroupV2) runJobs(ctx context.Context, bar *MPBV2) (allDone bool) {
+// runJobs runs one round of the unfinished jobs in this group.
+// ran is false if there is no job to run (only downloaders left).
+func (s *GroupV2) runJobs(ctx context.Context, bar *MPBV2) (allDone, ran bool) {
 	s.muTasks.RLock()
 	defer s.muTasks.RUnlock()
 	_ = ctx
@@ -88,12 +98,16 @@ func (s *GroupV2) runJobs(ctx context.Context, bar *MPBV2) (allDone bool) {
 	}()
 	for _, tsk := range s.tasks {
 		if tsk.job != nil {
-			if progress, _, done := tsk.Done(); !done {
-				if delta, err := tsk.job(bar, s, tsk, progress); err == nil {
-					if done := tsk.Increase(delta); done {
-						atomic.StoreInt64(&tsk.progress, tsk.Max())
-						atomic.AddInt32(&s.done, 1)
-					}
+			if progress, _, done := tsk.Done(); !done && tsk.Err() == nil {
+				ran = true
+				tsk.markRunning()
+				if delta, err := tsk.job(bar, s, tsk, progress); err != nil {
+					// a failed job isn't retried, it's finished.
+					tsk.fail(err)
+					atomic.AddInt32(&s.done, 1)
+				} else if done := tsk.Increase(delta); done {
+					atomic.StoreInt64(&tsk.progress, tsk.Max())
+					atomic.AddInt32(&s.done, 1)
 				}
 			}
 			select {
@@ -119,6 +133,25 @@ func (s *GroupV2) repaint(pc *paintCtx) (ignored bool) {
 	if s.muTasks.TryRLock() {
 		defer s.muTasks.RUnlock()
 
+		out := pc.bm.out
+		sampleRates(s.tasks)
+		if p := pc.bm.json; p != nil {
+			for _, tsk := range s.tasks {
+				p.update(s.Name, tsk)
+			}
+			if s.allDone() {
+				p.groupDone(s.Name)
+			}
+			return
+		}
+		if p := pc.bm.plain; p != nil {
+			p.header(out, s.Name)
+			for _, tsk := range s.tasks {
+				p.print(out, tsk)
+			}
+			return
+		}
+
 		if atomic.CompareAndSwapInt32(&s.titlePainted, 0, 1) {
 			// if is.InTracing() {
 			// 	println(fmt.Sprintf("%s (%v, %v, %v)", s.Name,
@@ -126,16 +159,38 @@ func (s *GroupV2) repaint(pc *paintCtx) (ignored bool) {
 			// } else {
 			// 	println(fmt.Sprintf("%s", s.Name))
 			// }
-			println(s.Name)
+			s.title = groupTitle(pc.bm.header, pc.bm.prof(), s.Name, len(s.tasks))
+			if cols, _ := termSize(); cols > 0 {
+				s.title = ellipsize(s.title, cols-1)
+			}
+			_, _ = io.WriteString(out, s.title+"\n")
+		}
+
+		if lines := pc.bm.logs.take(); len(lines) > 0 {
+			// print the logs above the live area, and start a
+			// new area below them.
+			s.block.Clear()
+			writeLines(out, lines...)
+			s.block = newRowsBlock(out)
+		}
+		if atomic.CompareAndSwapInt32(&pc.bm.resized, 1, 0) {
+			// erase the lines wrapped by the terminal below the
+			// live area, and start a new area there.
+			s.block.Clear()
+			_, _ = io.WriteString(out, eraseBelow)
+			s.block = newRowsBlock(out)
 		}
-		_ = pc
 
 		var sb strings.Builder
-		for i, tsk := range s.tasks {
-			// tsk.repaint(&sb, i, pc)
+		tasks, summary := viewport(s.tasks, rowsBelow(maxLiveRows(), s.title))
+		measure(s.table, tasks)
+		for _, tsk := range tasks {
 			_, _ = sb.WriteString(tsk.stepper.String(tsk))
 			_, _ = sb.WriteRune('\n')
-			_ = i
+		}
+		if summary != "" {
+			_, _ = sb.WriteString(summary)
+			_, _ = sb.WriteRune('\n')
 		}
 
 		s.block.Update(sb.String())
@@ -1,21 +1,10 @@
 package progressbar
 
 import (
-	"strconv"
-	"strings"
 	"sync/atomic"
 	"time"
 )
 
-func (s *TaskBar) repaint(w Writer, i int, pc *paintCtx) {
-	n, _ := w.WriteString(s.Name)
-	_, _ = w.WriteString(strings.Repeat(" ", 32-n))
-	progress := s.Progress()
-	_, _ = w.WriteString(strconv.Itoa(int(progress)))
-	_, _ = w.WriteString("\n")
-	_, _ = i, pc
-}
-
 // String implements PB.
 func (s *TaskBar) String() string {
 	return s.stepper.String(s)
@@ -33,9 +22,8 @@ func (s *TaskBar) State() (min, max, pos int64) {
 }
 
 func (s *TaskBar) Completed() bool {
-	pos := atomic.LoadInt64(&s.progress)
-	max := atomic.LoadInt64(&s.max)
-	return pos >= max
+	min, max, pos := s.State()
+	return reached(min, max, pos)
 }
 
 func (s *TaskBar) Min() int64      { return atomic.LoadInt64(&s.min) }
@@ -43,14 +31,15 @@ func (s *TaskBar) Max() int64      { return atomic.LoadInt64(&s.max) }
 func (s *TaskBar) Progress() int64 { return atomic.LoadInt64(&s.progress) }
 func (s *TaskBar) Increase(delta int64) (done bool) {
 	val := atomic.AddInt64(&s.progress, delta)
-	return val >= s.Max()
+	return reached(s.Min(), s.Max(), val)
 }
 
 func (s *TaskBar) Done() (progress, max int64, done bool) {
-	_, max, progress = s.State()
+	var min int64
+	min, max, progress = s.State()
 	// progress = atomic.LoadInt64(&s.progress)
 	// max = atomic.LoadInt64(&s.max)
-	done = progress >= max
+	done = reached(min, max, progress)
 	return
 }
 
@@ -89,23 +78,22 @@ func (s *TaskBar) LowerBound() (lb int64) {
 
 // Percent implements PB.
 func (s *TaskBar) Percent() string {
-	lb, ub, progress := s.State()
-	percent := float64(progress) / float64(ub-lb)
-	return fltfmtpercent(percent)
+	return fltfmtpercent(s.PercentF())
 }
 
-// PercentF implements PB.
+// PercentF implements PB. It returns 0 if the total is unknown.
 func (s *TaskBar) PercentF() float64 {
 	lb, ub, progress := s.State()
+	if indeterminate(lb, ub) {
+		return 0
+	}
 	percent := float64(progress) / float64(ub-lb)
 	return percent
 }
 
 // PercentI implements PB.
 func (s *TaskBar) PercentI() int {
-	lb, ub, progress := s.State()
-	percent := float64(progress) / float64(ub-lb)
-	return int(percent*100 + 0.5)
+	return int(s.PercentF()*100 + 0.5)
 }
 
 // Resumeable implements PB.
@@ -116,6 +104,7 @@ func (s *TaskBar) Resumeable() bool {
 // SetInitialValue implements PB.
 func (s *TaskBar) SetInitialValue(initial int64) {
 	atomic.StoreInt64(&s.progress, initial)
+	s.rate.reset()
 }
 
 // SetResumeable implements PB.
@@ -127,16 +116,100 @@ func (s *TaskBar) Step(delta int64) {
 	_ = s.Increase(delta)
 }
 
+// StepSegment updates the progress by delta, and counts it into the
+// segment name, such as "succeeded" or "failed". The stepper draws
+// the segments stacked, see WithStepperSegments.
+func (s *TaskBar) StepSegment(name string, delta int64) {
+	s.segs.add(name, delta)
+	_ = s.Increase(delta)
+}
+
+// Segments returns the counts of the segments stepped by
+// StepSegment.
+func (s *TaskBar) Segments() map[string]int64 {
+	return s.segs.snapshot()
+}
+
+func (s *TaskBar) estimator() *rateEstimator { return &s.rate }
+
+func (s *TaskBar) unit() Unit { return unitOf(s.stepper) }
+
+func (s *TaskBar) layout() *tableLayout { return s.table }
+
+func (s *TaskBar) started() time.Time {
+	s.muTime.Lock()
+	defer s.muTime.Unlock()
+	return s.startTime
+}
+
+// Bar returns the stepper or spinner of the task, or nil if it isn't
+// picked yet.
+func (s *TaskBar) Bar() BarT     { return s.stepper }
+func (s *TaskBar) userData() any { return s.data }
+
+// Dur returns the elapsed time since the task started running, or
+// zero if it isn't started yet.
 func (pb *TaskBar) Dur() (dur time.Duration) {
-	if _, _, done := pb.Done(); !done {
+	_, _, done := pb.Done()
+	stopped := done || pb.Err() != nil
+
+	pb.muTime.Lock()
+	defer pb.muTime.Unlock()
+	if pb.startTime.IsZero() {
+		return
+	}
+	if !stopped {
 		pb.stopTime = time.Now()
 	}
 	dur = pb.stopTime.Sub(pb.startTime)
 	return
 }
 
+// Status returns the state of the task.
+func (s *TaskBar) Status() TaskState {
+	switch err := s.Err(); {
+	case err != nil:
+		return stateOfErr(err)
+	case s.Completed():
+		return TaskSucceeded
+	case s.started().IsZero():
+		return TaskPending
+	}
+	return TaskRunning
+}
+
+// Err returns the error if the task failed or was cancelled, or nil.
+func (s *TaskBar) Err() error {
+	s.muErr.Lock()
+	defer s.muErr.Unlock()
+	return s.err
+}
+
+func (s *TaskBar) fail(err error) {
+	s.muErr.Lock()
+	defer s.muErr.Unlock()
+	if s.err == nil {
+		s.err = err
+		// the elapsed time stops at the failure.
+		s.muTime.Lock()
+		if !s.startTime.IsZero() {
+			s.stopTime = time.Now()
+		}
+		s.muTime.Unlock()
+	}
+}
+
+// markRunning records the start time when the task runs first.
+func (s *TaskBar) markRunning() {
+	if atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		s.startNow()
+	}
+}
+
 func (s *TaskBar) startNow() {
 	now := time.Now()
+	s.muTime.Lock()
+	defer s.muTime.Unlock()
 	s.startTime = now.Add(-1 * time.Millisecond)
 	s.stopTime = now
 }
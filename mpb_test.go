@@ -1,13 +1,18 @@
 package progressbar
 
 import (
+	"bytes"
 	"context"
 	"math/rand"
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/hedzr/progressbar/v2/pbtest"
 )
 
 // var versions = []string{"1.16.1", "1.17.1", "1.18.1", "1.19.1", "1.20.1", "1.21.1", "1.22.1", "1.23.1", "1.24.1"}
@@ -57,6 +62,212 @@ func TestNewV2(t *testing.T) {
 	mpb.Run(ctx)
 }
 
+func TestNewV2WithOutput(t *testing.T) {
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 10, nil
+	}
+
+	for _, mode := range []RenderMode{RenderPlain, RenderTTY} {
+		var buf bytes.Buffer
+		mpb := NewV2(WithOutput(&buf), WithRenderMode(mode), WithRefreshInterval(time.Millisecond))
+		for i := range 2 {
+			_ = mpb.AddBar("Group "+strconv.Itoa(i), "Task #"+strconv.Itoa(i), 0, 100, job)
+		}
+		mpb.Run(context.Background())
+		mpb.Close()
+
+		out := buf.String()
+		for _, want := range []string{"Group 0\n", "Group 1\n", "Task #0", "Task #1"} {
+			if !strings.Contains(out, want) {
+				t.Fatalf("%v mode: expect %q in output, but got %q", mode, want, out)
+			}
+		}
+		if hasEsc := strings.Contains(out, "\x1b["); hasEsc != (mode == RenderTTY) {
+			t.Fatalf("%v mode: unexpected escape sequences state (%v) in output %q", mode, hasEsc, out)
+		}
+	}
+}
+
+func TestMPBV2Println(t *testing.T) {
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		if progress == 50 {
+			bar.Printf("half of %s", tsk.Name)
+			bar.Logger().Info("half", "task", tsk.Name)
+		}
+		return 10, nil
+	}
+
+	var buf bytes.Buffer
+	mpb := NewV2(WithOutput(&buf), WithRenderMode(RenderTTY), WithRefreshInterval(time.Millisecond))
+	_ = mpb.AddBar("Group", "Task #0", 0, 100, job)
+	mpb.Println("before run")
+	mpb.Run(context.Background())
+	mpb.Println("after run")
+	mpb.Close()
+
+	out := buf.String()
+	for _, want := range []string{"before run\n", "half of Task #0\n", "msg=half task=\"Task #0\"\n", "after run\n"} {
+		if strings.Count(out, want) != 1 {
+			t.Fatalf("expect %q printed once, but got %q", want, out)
+		}
+	}
+}
+
+func TestMPBV2LoggerNotLive(t *testing.T) {
+	var logs bytes.Buffer
+	w := logOut
+	logOut = &logs
+	defer func() { logOut = w }()
+
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		if progress == 50 {
+			bar.Logger().Info("half", "task", tsk.Name)
+		}
+		return 10, nil
+	}
+
+	for _, mode := range []RenderMode{RenderPlain, RenderJSON} {
+		logs.Reset()
+		var buf bytes.Buffer
+		mpb := NewV2(WithOutput(&buf), WithRenderMode(mode), WithRefreshInterval(time.Millisecond))
+		_ = mpb.AddBar("Group", "Task #0", 0, 100, job)
+		mpb.Logger().Info("before run")
+		mpb.Run(context.Background())
+		mpb.Close()
+
+		if strings.Contains(buf.String(), "msg=") {
+			t.Fatalf("%v mode: expect no logs in output, but got %q", mode, buf.String())
+		}
+		for _, want := range []string{"msg=\"before run\"\n", "msg=half task=\"Task #0\"\n"} {
+			if !strings.Contains(logs.String(), want) {
+				t.Fatalf("%v mode: expect %q logged, but got %q", mode, want, logs.String())
+			}
+		}
+	}
+}
+
+func TestNewV2Resized(t *testing.T) {
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		if progress == 50 {
+			bar.onResized()
+		}
+		return 10, nil
+	}
+
+	var buf bytes.Buffer
+	mpb := NewV2(WithOutput(&buf), WithRenderMode(RenderTTY), WithRefreshInterval(time.Millisecond))
+	_ = mpb.AddBar("Group", "Task #0", 0, 100, job)
+	mpb.Run(context.Background())
+	mpb.Close()
+
+	if n := strings.Count(buf.String(), eraseBelow); n != 1 {
+		t.Fatalf("expect the screen below erased once after resized, but got %d", n)
+	}
+}
+
+func TestNewV2Golden(t *testing.T) {
+	fixTermSize(t, 60, 10)
+
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 25, nil
+	}
+
+	term := pbtest.New(60, 10)
+	mpb := NewV2(
+		WithOutput(term), WithRenderMode(RenderTTY), WithRefreshInterval(time.Millisecond),
+		WithProfile(ProfileTrueColor),
+		WithSchema(`{{.Title}} {{.Bar}} {{.Percent}} {{.Current}}/{{.Total}}`),
+	)
+	for i := range 2 {
+		for j := range 2 {
+			_ = mpb.AddBar("Group "+strconv.Itoa(i), "Task #"+strconv.Itoa(j), 0, 100, job)
+		}
+	}
+	mpb.Run(context.Background())
+	mpb.Close()
+
+	if _, _, visible := term.Cursor(); !visible {
+		t.Fatal("expect cursor restored")
+	}
+	pbtest.Golden(t, "testdata/mpbv2.golden", term.Screen())
+}
+
+func TestNewV2GoldenWideTitles(t *testing.T) {
+	fixTermSize(t, 40, 10)
+
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 50, nil
+	}
+
+	term := pbtest.New(40, 10)
+	mpb := NewV2(
+		WithOutput(term), WithRenderMode(RenderTTY), WithRefreshInterval(time.Millisecond),
+		WithProfile(ProfileTrueColor),
+		WithTaskOpts(
+			WithTaskBarStepper(2, WithStepperSchema(`{{.Title}} {{.Bar}} {{.Percent}}`), WithStepperWidth(AutoWidth)),
+			WithTaskBarTitleMaxWidth(10),
+		),
+	)
+	_ = mpb.AddBar("下载组 with a very long group name exceeding the width", "下载文件.tar.gz", 0, 100, job)
+	_ = mpb.AddBar("下载组 with a very long group name exceeding the width", "\U0001F30D world", 0, 100, job)
+	mpb.Run(context.Background())
+	mpb.Close()
+
+	pbtest.Golden(t, "testdata/mpbv2-wide.golden", term.Screen())
+}
+
+func TestNewV2PerBarStepper(t *testing.T) {
+	fixTermSize(t, 80, 10)
+
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 10, nil
+	}
+
+	var buf bytes.Buffer
+	mpb := NewV2(WithOutput(&buf), WithRenderMode(RenderTTY), WithRefreshInterval(time.Millisecond))
+	for i := range 4 {
+		_ = mpb.AddBar("Group", "Task #"+strconv.Itoa(i), 0, 100, job,
+			WithTaskBarStepper(0, WithStepperWidth(10*(i+1)), WithStepperSchema(`{{.Title}} {{.Bar}}`)),
+		)
+	}
+
+	grp := mpb.GroupByIndex(0)
+	done := make(chan struct{})
+	go func() { // read the state of bars while they're painting
+		defer close(done)
+		for range 100 {
+			for i := range 4 {
+				_ = grp.TaskByIndex(i).stepper.PercentF()
+			}
+		}
+	}()
+	mpb.Run(context.Background())
+	<-done
+	mpb.Close()
+
+	for i := range 4 {
+		st, ok := grp.TaskByIndex(i).stepper.(*stepper)
+		if !ok || st.barWidth != 10*(i+1) || st.PercentF() != 1 {
+			t.Fatalf("Task #%d: expect its own stepper, but got %+v", i, st)
+		}
+	}
+	if st := steppers[0]; st.barWidth != 0 || st.schema != "" || st.tmpl != nil {
+		t.Fatalf("expect the built-in style untouched, but got %+v", st)
+	}
+}
+
+// fixTermSize pretends the terminal size is cols x rows while the
+// test is running.
+func fixTermSize(t *testing.T, cols, rows int) {
+	oldCols, oldRows := termSize()
+	atomic.StoreInt32(&termCols, int32(cols))
+	atomic.StoreInt32(&termRows, int32(rows))
+	t.Cleanup(func() {
+		atomic.StoreInt32(&termCols, int32(oldCols))
+		atomic.StoreInt32(&termRows, int32(oldRows))
+	})
+}
+
 type TitledUrl string
 
 func (t TitledUrl) String() string {
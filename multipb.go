@@ -4,12 +4,13 @@
 package progressbar
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"sync"
 	"sync/atomic"
-
-	"github.com/hedzr/is/term/color"
+	"text/template"
+	"time"
 )
 
 type MultiPB interface {
@@ -34,13 +35,16 @@ func multiBar(opts ...MOpt) *mpbar {
 		out:       os.Stdout,
 		sigRedraw: make(chan struct{}, 16),
 		sigExit:   make(chan struct{}, 16),
+		heartbeat: defaultHeartbeat,
 	}
 
 	for _, opt := range opts {
 		opt(bar)
 	}
+	bar.resolveMode()
+	bar.unwatch = addResizeListener(bar.onResized(bar.Redraw))
 
-	go bar.run()
+	go bar.run(bar.sigRedraw, bar.sigExit)
 	return bar
 }
 
@@ -56,17 +60,101 @@ type mpbar struct {
 
 	dirtyFlag int32
 	closed    int32
-	lines     int
+	heartbeat time.Duration // redraw at least once per heartbeat, 0 to disable
+	lines     int           // the bars above have been done and committed
+	painted   int           // how many lines were painted in last redraw
+
+	mode  RenderMode
+	plain *plainPrinter // non-nil in RenderPlain mode
+	json  *jsonPrinter  // non-nil in RenderJSON mode
+
+	tp *terminalProgress // non-nil if WithMultiBarTerminalProgress
+
+	profile *Profile           // non-nil if WithMultiBarProfile
+	theme   *Theme             // non-nil if WithMultiBarTheme
+	funcs   template.FuncMap   // see WithMultiBarSchemaFuncs
+	table   *tableLayout       // non-nil if WithMultiBarTableLayout
+	header  *template.Template // the group header of theme
+
+	resized int32  // terminal window was resized since last redraw
+	unwatch func() // unregister the resize listener
+
+	logs lineBuffer // the lines printed above the live bars
 
 	// logger *slog.Logger
 }
 
+func (mpb *mpbar) resolveMode() {
+	switch mpb.mode = mpb.mode.resolve(mpb.out); mpb.mode {
+	case RenderPlain:
+		mpb.plain = newPlainPrinter()
+	case RenderJSON:
+		mpb.json = newJSONPrinter(mpb.out)
+	default:
+		mpb.logs.setLive(true)
+	}
+	if mpb.mode != RenderTTY {
+		mpb.tp = nil
+	} else {
+		measureTerm(mpb.out)
+	}
+}
+
+func (mpb *mpbar) Println(args ...any) { mpb.printLine(fmt.Sprintln(args...)) }
+
+func (mpb *mpbar) Printf(format string, args ...any) { mpb.printLine(fmt.Sprintf(format, args...)) }
+
+func (mpb *mpbar) printLine(line string) {
+	if mpb.json != nil {
+		mpb.json.log(line)
+		return
+	}
+	if mpb.logs.add(line) {
+		mpb.Redraw()
+		return
+	}
+	writeLines(mpb.out, line)
+}
+
+func (mpb *mpbar) printLog(line string) {
+	if mpb.logs.add(line) {
+		mpb.Redraw()
+		return
+	}
+	writeLines(logOut, line)
+}
+
+// printLogs prints the buffered lines above the live area, the
+// cursor should be at the top of the live area.
+func (mpb *mpbar) printLogs() {
+	if lines := mpb.logs.take(); len(lines) > 0 {
+		for _, line := range lines {
+			_, _ = io.WriteString(mpb.out, eraseLine+line+"\n")
+		}
+		_, _ = io.WriteString(mpb.out, eraseBelow)
+	}
+}
+
+func (mpb *mpbar) onResized(redraw func()) func() {
+	return func() {
+		atomic.StoreInt32(&mpb.resized, 1)
+		redraw()
+	}
+}
+
 func (mpb *mpbar) Cancel() {
 	mpb.Close()
 }
 
 func (mpb *mpbar) Close() {
 	if atomic.CompareAndSwapInt32(&mpb.closed, 0, 1) {
+		if mpb.unwatch != nil {
+			mpb.unwatch()
+		}
+		writeLines(mpb.out, mpb.logs.setLive(false)...)
+		if mpb.tp != nil {
+			mpb.tp.clear(mpb.out)
+		}
 		close(mpb.sigExit)
 
 		if mpb.bars != nil {
@@ -141,15 +229,62 @@ func (mpb *mpbar) PercentI(index int) int {
 }
 
 func (mpb *mpbar) Add(maxBytes int64, title string, opts ...Opt) (index int) {
-	pb := defaultBytes(mpb, maxBytes, title, opts...).(*pbar) //nolint:errcheck //the call is always ok
+	pb := defaultBytes(mpb, maxBytes, title, mpb.barOpts(opts)...).(*pbar) //nolint:errcheck //the call is always ok
 	pb.stepper.SetIndentChars(indentChars)
+	mpb.applyProfile(pb)
 
 	mpb.rw.Lock()
 	mpb.bars = append(mpb.bars, pb)
 	mpb.rw.Unlock()
+	if mpb.json != nil {
+		mpb.json.added("", pb)
+	}
 	return len(mpb.bars) - 1
 }
 
+// barOpts prepends the table layout, the theme and the schema
+// functions to the options of a bar. The theme and the functions are
+// kept as the defaults of the stepper, so they survive a bar picking
+// another stepper or spinner.
+func (mpb *mpbar) barOpts(opts []Opt) []Opt {
+	if mpb.table != nil {
+		opts = append([]Opt{func(pb *pbar) { pb.table = mpb.table }}, opts...)
+	}
+	var defaults []StepperOpt
+	if mpb.theme != nil {
+		defaults = append(defaults, WithStepperTheme(*mpb.theme))
+	}
+	if mpb.funcs != nil {
+		defaults = append(defaults, WithStepperSchemaFuncs(mpb.funcs))
+	}
+	if len(defaults) == 0 {
+		return opts
+	}
+	return append([]Opt{func(pb *pbar) {
+		pb.stepperOpts = defaults
+		for _, opt := range defaults {
+			opt(pb.stepper)
+		}
+	}}, opts...)
+}
+
+// prof returns the profile specified by WithMultiBarProfile, or
+// the detected one.
+func (mpb *mpbar) prof() Profile {
+	if mpb.profile != nil {
+		return *mpb.profile
+	}
+	return DetectProfile()
+}
+
+// applyProfile applies the profile specified by WithMultiBarProfile
+// to pb.
+func (mpb *mpbar) applyProfile(pb *pbar) {
+	if mpb.profile != nil {
+		pb.stepper.SetProfile(*mpb.profile)
+	}
+}
+
 func (mpb *mpbar) Remove(index int) {
 	mpb.rw.Lock()
 	defer mpb.rw.Unlock()
@@ -158,23 +293,46 @@ func (mpb *mpbar) Remove(index int) {
 	}
 }
 
-func (mpb *mpbar) run() {
-	// // ticker := time.NewTicker(time.Millisecond * 50)
-	// ticker := time.NewTicker(time.Second * 1)
-	// defer ticker.Stop()
+// run redraws the bars on signals, and on each heartbeat while
+// some bars are running. The channels are passed in since Close
+// resets the fields.
+func (mpb *mpbar) run(sigRedraw, sigExit <-chan struct{}) {
+	beat, stop := heartbeat(mpb.mode, mpb.heartbeat)
+	defer stop()
 
 	for {
 		select {
-		// case <-ticker.C:
-		// 	mpb.redrawNow()
-		case <-mpb.sigRedraw:
+		case <-beat:
+			if mpb.running() {
+				mpb.redrawNow()
+			}
+		case _, ok := <-sigRedraw:
+			if !ok {
+				return
+			}
 			mpb.redrawNow()
-		case <-mpb.sigExit:
+		case <-sigExit:
 			return
 		}
 	}
 }
 
+// running tells whether some bars are still in progress.
+func (mpb *mpbar) running() bool {
+	mpb.rw.RLock()
+	defer mpb.rw.RUnlock()
+	return anyRunning(mpb.bars)
+}
+
+func anyRunning(bars []*pbar) bool {
+	for _, pb := range bars {
+		if pb.running() {
+			return true
+		}
+	}
+	return false
+}
+
 func (mpb *mpbar) redrawNow() {
 	if !mpb.rw.TryRLock() {
 		return
@@ -192,35 +350,34 @@ func (mpb *mpbar) redrawNow() {
 		return
 	}
 
-	var done = true
-	var cnt int
+	sampleRates(mpb.bars)
+	if mpb.plain != nil {
+		mpb.printPlain()
+		return
+	}
+	if mpb.json != nil {
+		mpb.printJSON()
+		return
+	}
+
+	if mpb.lines > len(mpb.bars) {
+		mpb.lines = len(mpb.bars)
+	}
 
 	var first = atomic.CompareAndSwapInt32(&mpb.dirtyFlag, 0, 1)
 	if !first {
-		color.Left(1000)
-		color.Up(len(mpb.bars) - mpb.lines)
-	}
-
-	for i, pb := range mpb.bars {
-		if i >= mpb.lines {
-			str := pb.String()
-			_, _ = mpb.out.Write([]byte(str))
-			_, _ = mpb.out.Write([]byte("\n"))
-			// _, _ = fmt.Fprintf(mpb.out, "%s%s\n", indentChars, str)
-			if !pb.completed {
-				done = false
-				cnt++
-			}
-		}
+		mpb.moveUp(mpb.painted)
 	}
+	mpb.printLogs()
 
-	// _, _ = fmt.Fprintf(tui, "%v tasks activate [%v, %v, %v lines]\n", cnt, width, tui.Height(), len(mpb.bars)+1)
-	// _ = tui.FlushN(len(mpb.bars) + 1)
-
+	done := mpb.drawBars(mpb.bars[mpb.lines:], maxLiveRows())
+	if mpb.tp != nil {
+		reportProgress(mpb.out, mpb.tp, mpb.bars)
+	}
 	if done {
 		// mpb.out.Flush()
 		if atomic.CompareAndSwapInt32(&mpb.dirtyFlag, 1, 0) {
-			mpb.lines = len(mpb.bars)
+			mpb.lines, mpb.painted = len(mpb.bars), 0
 		}
 		if mpb.onDone != nil {
 			cb := mpb.onDone
@@ -231,6 +388,78 @@ func (mpb *mpbar) redrawNow() {
 	}
 }
 
+// drawBars draws the bars within maxRows lines (see viewport),
+// and records how many lines were painted.
+func (mpb *mpbar) drawBars(bars []*pbar, maxRows int) (done bool) {
+	done = true
+	for _, pb := range bars {
+		if !pb.finished() {
+			done = false
+		}
+	}
+
+	visible, summary := viewport(bars, maxRows)
+	measure(mpb.table, visible)
+	for _, pb := range visible {
+		_, _ = mpb.out.Write([]byte(pb.String()))
+		_, _ = mpb.out.Write([]byte("\n"))
+	}
+	mpb.painted = len(visible)
+	if summary != "" {
+		_, _ = io.WriteString(mpb.out, summary+"\n")
+		mpb.painted++
+	}
+	return
+}
+
+// moveUp moves the cursor to the beginning of the line which is
+// n lines above, for redrawing the bars there.
+func (mpb *mpbar) moveUp(n int) {
+	_, _ = io.WriteString(mpb.out, "\r")
+	cursorUp(mpb.out, n)
+	mpb.eraseIfResized()
+}
+
+// eraseIfResized erases the screen below the cursor if the
+// terminal window was resized, so the lines wrapped by the
+// terminal can be cleaned up.
+func (mpb *mpbar) eraseIfResized() {
+	if atomic.CompareAndSwapInt32(&mpb.resized, 1, 0) {
+		_, _ = io.WriteString(mpb.out, eraseBelow)
+	}
+}
+
+// printPlain prints the bars as plain text lines, see RenderPlain.
+func (mpb *mpbar) printPlain() {
+	var done = true
+	for _, pb := range mpb.bars {
+		mpb.plain.print(mpb.out, pb)
+		if !pb.finished() {
+			done = false
+		}
+	}
+	if done && mpb.onDone != nil {
+		cb := mpb.onDone
+		mpb.onDone = nil
+		cb(mpb)
+	}
+}
+
+// printJSON emits the events of the bars, see RenderJSON.
+func (mpb *mpbar) printJSON() {
+	var done = true
+	for _, pb := range mpb.bars {
+		if !mpb.json.update("", pb) {
+			done = false
+		}
+	}
+	if done && mpb.onDone != nil {
+		cb := mpb.onDone
+		mpb.onDone = nil
+		cb(mpb)
+	}
+}
+
 func (mpb *mpbar) Write(data []byte) (n int, err error) {
 	n, err = mpb.out.Write(data)
 	// _ = mpb.out.Flush()
@@ -54,7 +54,7 @@ func NewGPB(opts ...MOpt) GroupedPB {
 }
 
 type SchemaData struct {
-	Data any // your customized data structure here
+	Data any // your customized data structure here, see WithTaskBarData and WithBarData
 
 	Indent  string
 	Prepend string
@@ -69,4 +69,46 @@ type SchemaData struct {
 
 	PercentFloat float64
 	ElapsedTime  time.Duration
+
+	// Segments are the counts of the segments stepped by
+	// StepSegment, such as {{.Segments.failed}}. It is nil if no
+	// segment was stepped.
+	Segments map[string]int64
+
+	// Speed is smoothed over the last seconds (an EWMA), so it
+	// recovers from a stall soon. InstantSpeed is the speed within
+	// the last sample, taken at most once per 100ms, AvgSpeed is the
+	// average since the start, or
+	// since SetInitialValue for a resumed download.
+	InstantSpeed string
+	AvgSpeed     string
+
+	// ETA is the estimated time to go, such as "1m5s", or "-" if it
+	// is unknown. Remaining is the same one, or -1 if unknown, and
+	// FinishAt is the estimated time to complete, or zero if unknown.
+	ETA       string
+	Remaining time.Duration
+	FinishAt  time.Time
+
+	// Indeterminate is true if the total is unknown (UnknownTotal),
+	// Percent is blank and Total is "?" in this case, Current and
+	// Speed are still valid.
+	Indeterminate bool
+
+	// State is the TaskState of the bar, "pending", "running",
+	// "succeeded", "failed" or "cancelled", for the conditions such
+	// as {{if eq .State "failed"}}. Error is the message of the error
+	// of a failed or cancelled bar, or empty.
+	State string
+	Error string
+
+	// Min, Max and Pos are the raw state of the bar, for comparing
+	// or computing such as {{if gt .Pos 1000}}. Max is UnknownTotal
+	// if the total is unknown. BytesPerSec is the raw Speed, in
+	// units per second.
+	Min, Max, Pos int64
+	BytesPerSec   float64
+	StartTime     time.Time // zero if not started yet
+	Done          bool      // the bar is completed
+	Failed        bool      // the bar failed, see Err of the bar
 }
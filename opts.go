@@ -5,22 +5,43 @@ package progressbar
 
 import (
 	"io"
+	"log"
+	"text/template"
+	"time"
 )
 
 type Opt func(pb *pbar)
 
 func WithBarSpinner(whichOne int) Opt {
 	return func(pb *pbar) {
-		if s, ok := spinners[whichOne]; ok {
-			pb.stepper = s.init()
+		if s, ok := spinnerAt(whichOne); ok {
+			pb.stepper = s.clone(pb.stepperOpts...)
 		}
 	}
 }
 
 func WithBarStepper(whichOne int) Opt {
 	return func(pb *pbar) {
-		if s, ok := steppers[whichOne]; ok {
-			pb.stepper = s.init()
+		if s, ok := stepperAt(whichOne); ok {
+			pb.stepper = s.clone(pb.stepperOpts...)
+		}
+	}
+}
+
+// WithBarSpinnerNamed picks a spinner registered by RegisterSpinner.
+func WithBarSpinnerNamed(name string) Opt {
+	return func(pb *pbar) {
+		if s, ok := spinnerNamed(name); ok {
+			pb.stepper = s.clone(pb.stepperOpts...)
+		}
+	}
+}
+
+// WithBarStepperNamed picks a stepper registered by RegisterStepper.
+func WithBarStepperNamed(name string) Opt {
+	return func(pb *pbar) {
+		if s, ok := stepperNamed(name); ok {
+			pb.stepper = s.clone(pb.stepperOpts...)
 		}
 	}
 }
@@ -37,6 +58,8 @@ func WithBarUpperBound(ub int64) Opt {
 	}
 }
 
+// WithBarWidth sets the width of the bar. Use AutoWidth to fill
+// the remaining columns of the terminal.
 func WithBarWidth(w int) Opt {
 	return func(pb *pbar) {
 		pb.stepper.SetWidth(w)
@@ -94,6 +117,63 @@ func WithBarExtraTailSpaces(howMany int) Opt {
 	}
 }
 
+// WithBarTitleMaxWidth limits the title to w terminal columns, a
+// longer title will be cut with an ellipsis. Default is 0 (no
+// limit).
+func WithBarTitleMaxWidth(w int) Opt {
+	return func(pb *pbar) {
+		pb.stepper.SetTitleMaxWidth(w)
+	}
+}
+
+// WithBarPartials specifies the glyphs of a cell from the least
+// to the fully filled, see WithStepperPartials.
+func WithBarPartials(glyphs ...string) Opt {
+	return func(pb *pbar) {
+		pb.stepper.SetPartials(glyphs...)
+	}
+}
+
+// WithBarUnit specifies how Current, Total and the speeds are
+// formatted, see WithStepperUnit.
+func WithBarUnit(u Unit) Opt {
+	return func(pb *pbar) {
+		pb.stepper.SetUnit(u)
+	}
+}
+
+// WithBarSchemaFuncs adds funcs to the functions of the schema, see
+// WithStepperSchemaFuncs.
+func WithBarSchemaFuncs(funcs template.FuncMap) Opt {
+	return func(pb *pbar) {
+		pb.stepper.SetSchemaFuncs(funcs)
+	}
+}
+
+// WithBarSegments specifies the segments of a stacked bar, see
+// WithStepperSegments and StepSegment.
+func WithBarSegments(segs ...Segment) Opt {
+	return func(pb *pbar) {
+		pb.stepper.SetSegments(segs...)
+	}
+}
+
+// WithBarProfile specifies the color and glyph capabilities of the
+// terminal, instead of the detected one (see DetectProfile).
+func WithBarProfile(p Profile) Opt {
+	return func(pb *pbar) {
+		pb.stepper.SetProfile(p)
+	}
+}
+
+// WithBarTheme applies the glyphs, colors and schema of a theme,
+// such as ThemeCargo.
+func WithBarTheme(t Theme) Opt {
+	return func(pb *pbar) {
+		pb.stepper.SetTheme(t)
+	}
+}
+
 func WithBarWorker(w Worker) Opt {
 	return func(pb *pbar) {
 		pb.worker = w
@@ -118,6 +198,14 @@ func WithBarOnDataPrepared(cb OnDataPrepared) Opt {
 	}
 }
 
+// WithBarData attaches your data to the bar, as the Data of
+// SchemaData, such as {{.Data.Host}}.
+func WithBarData(data any) Opt {
+	return func(pb *pbar) {
+		pb.data = data
+	}
+}
+
 // func WithBarLogger(logger *slog.Logger) Opt {
 // 	return func(pb *pbar) {
 // 		pb.logger = logger
@@ -140,3 +228,77 @@ func WithOutputDevice(out io.Writer) MOpt {
 		mpb.out = out
 	}
 }
+
+// WithMultiBarRenderMode specifies how the bars are drawn.
+//
+// Default is RenderAuto, which draws animated bars if the output
+// device is a terminal, and prints plain text lines if not.
+func WithMultiBarRenderMode(mode RenderMode) MOpt {
+	return func(mpb *mpbar) {
+		mpb.mode = mode
+	}
+}
+
+// WithMultiBarTerminalProgress reports the overall progress of all
+// bars to the terminal on each frame: the tab/taskbar progress
+// indicator via the sequence OSC 9;4, and the percent in the window
+// title via OSC 0 if title isn't empty.
+//
+// They are cleared when the MultiPB is closed. It takes effect in
+// RenderTTY mode only.
+func WithMultiBarTerminalProgress(title string) MOpt {
+	return func(mpb *mpbar) {
+		mpb.tp = newTerminalProgress(title)
+	}
+}
+
+// WithMultiBarHeartbeat specifies how often the bars are redrawn
+// even if no progress was made, so the spinners keep turning and
+// the elapsed time keeps counting. Default is 100ms, 0 disables it.
+//
+// It takes effect in RenderTTY mode only, and stops once all bars
+// are done.
+func WithMultiBarHeartbeat(d time.Duration) MOpt {
+	return func(mpb *mpbar) {
+		mpb.heartbeat = max(d, 0)
+	}
+}
+
+// WithMultiBarProfile specifies the color and glyph capabilities of
+// the terminal for all bars, instead of the detected one (see
+// DetectProfile).
+func WithMultiBarProfile(p Profile) MOpt {
+	return func(mpb *mpbar) {
+		mpb.profile = &p
+	}
+}
+
+// WithMultiBarSchemaFuncs adds funcs to the functions of the
+// schemas of all bars, see WithSchemaFuncs.
+func WithMultiBarSchemaFuncs(funcs template.FuncMap) MOpt {
+	return func(mpb *mpbar) {
+		mpb.funcs = mergeFuncs(mpb.funcs, funcs)
+	}
+}
+
+// WithMultiBarTableLayout aligns the columns of all bars like a
+// table, across the groups too, see WithTableLayout.
+func WithMultiBarTableLayout(aligns ...ColumnAlign) MOpt {
+	return func(mpb *mpbar) {
+		mpb.table = newTableLayout(aligns)
+	}
+}
+
+// WithMultiBarTheme applies a theme, such as ThemeCargo, to all
+// bars and group headers. The options of each bar take precedence
+// over the theme. An invalid GroupHeader is logged, and the group
+// names are drawn as is then.
+func WithMultiBarTheme(t Theme) MOpt {
+	return func(mpb *mpbar) {
+		var err error
+		mpb.theme = &t
+		if mpb.header, err = t.header(); err != nil {
+			log.Printf("Error: %v", err)
+		}
+	}
+}
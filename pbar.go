@@ -10,11 +10,12 @@ import (
 )
 
 func defaultBytes(mpbar MultiPB, maxBytes int64, title string, opts ...Opt) PB {
+	s, _ := stepperAt(0)
 	pb := &pbar{
 		mpbar:     mpbar,
 		max:       maxBytes,
 		title:     title,
-		stepper:   steppers[0].init(),
+		stepper:   s.clone(),
 		startTime: time.Now(),
 		// sigRedraw: make(chan struct{}),
 		// sigExit:   make(chan struct{}),
@@ -69,11 +70,14 @@ type pbar struct {
 	stepper         BarT           // stepper or spinner here
 	mpbar           MultiPB        //
 	stepperPostInit func(bar BarT) //
+	stepperOpts     []StepperOpt   // applied to each stepper picked
 
 	worker         Worker
 	onComp         OnCompleted
 	onStart        OnStart
 	onDataPrepared OnDataPrepared
+	data           any          // see WithBarData
+	table          *tableLayout // non-nil if WithMultiBarTableLayout
 
 	title string
 
@@ -83,8 +87,13 @@ type pbar struct {
 	row  int
 
 	muPainting sync.RWMutex
+	muDone     sync.Mutex // guards completed, failed and stopTime for the readers not holding muPainting
 
 	completed bool
+	failed    bool       // err is set, the stop time is frozen
+	err       error      // set once the bar failed
+	segs      segCounter // see StepSegment
+	rate      rateEstimator
 
 	// logger *slog.Logger
 }
@@ -114,6 +123,7 @@ func (pb *pbar) SetInitialValue(v int64) {
 
 	pb.read = v
 	pb.stepper.SetInitialValue(v)
+	pb.rate.reset()
 }
 
 func (pb *pbar) Bar() BarT            { return pb.stepper }
@@ -141,21 +151,66 @@ func (pb *pbar) State() (min, max, pos int64) {
 }
 
 func (pb *pbar) Dur() (dur time.Duration) {
-	if !pb.completed {
+	pb.muDone.Lock()
+	defer pb.muDone.Unlock()
+	if !pb.completed && !pb.failed {
 		pb.stopTime = time.Now()
 	}
 	dur = pb.stopTime.Sub(pb.startTime)
 	return
 }
 func (pb *pbar) Completed() bool {
+	pb.muDone.Lock()
+	defer pb.muDone.Unlock()
 	return pb.completed
 }
 
+// Err returns the error if the bar failed, or nil.
+func (pb *pbar) Err() error {
+	pb.muPainting.RLock()
+	defer pb.muPainting.RUnlock()
+	return pb.err
+}
+
+func (pb *pbar) fail(err error) {
+	pb.muPainting.Lock()
+	if pb.err == nil {
+		pb.err = err
+		// the elapsed time stops at the failure.
+		pb.muDone.Lock()
+		if !pb.completed {
+			pb.stopTime = time.Now()
+		}
+		pb.failed = true
+		pb.muDone.Unlock()
+	}
+	pb.muPainting.Unlock()
+	pb.redraw()
+}
+
+// finished tells whether the bar was completed or failed.
+func (pb *pbar) finished() bool {
+	return pb.Completed() || pb.Err() != nil
+}
+
+// running tells whether the bar is neither completed nor failed.
+func (pb *pbar) running() bool {
+	pb.muPainting.RLock()
+	defer pb.muPainting.RUnlock()
+	return !pb.completed && pb.err == nil
+}
+
+// UpdateRange modifies the bounds. A bar whose total was unknown
+// is completed if the position already reaches the new upper bound.
 func (pb *pbar) UpdateRange(min, max int64) {
 	pb.muPainting.Lock()
 	defer pb.muPainting.Unlock()
 
+	unknown := indeterminate(pb.min, pb.max)
 	pb.min, pb.max = min, max
+	if unknown && reached(min, max, pb.read) {
+		pb.invalidate()
+	}
 }
 
 func (pb *pbar) Step(delta int64) {
@@ -166,6 +221,27 @@ func (pb *pbar) Step(delta int64) {
 	pb.invalidate()
 }
 
+// StepSegment updates the progress by delta, and counts it into the
+// segment name. See WithStepperSegments.
+func (pb *pbar) StepSegment(name string, delta int64) {
+	pb.segs.add(name, delta)
+	pb.Step(delta)
+}
+
+// Segments returns the counts of the segments stepped by
+// StepSegment.
+func (pb *pbar) Segments() map[string]int64 {
+	return pb.segs.snapshot()
+}
+
+func (pb *pbar) estimator() *rateEstimator { return &pb.rate }
+
+func (pb *pbar) unit() Unit { return unitOf(pb.stepper) }
+
+func (pb *pbar) started() time.Time   { return pb.startTime }
+func (pb *pbar) layout() *tableLayout { return pb.table }
+func (pb *pbar) userData() any        { return pb.data }
+
 func (pb *pbar) Write(data []byte) (n int, err error) {
 	pb.muPainting.Lock()
 	defer pb.muPainting.Unlock()
@@ -177,8 +253,13 @@ func (pb *pbar) Write(data []byte) (n int, err error) {
 }
 
 func (pb *pbar) invalidate() {
-	if pb.read >= pb.max {
+	if reached(pb.min, pb.max, pb.read) {
+		pb.muDone.Lock()
+		if !pb.completed {
+			pb.stopTime = time.Now()
+		}
 		pb.completed = true
+		pb.muDone.Unlock()
 
 		if pb.onComp != nil {
 			cb := pb.onComp
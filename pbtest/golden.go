@@ -0,0 +1,40 @@
+package pbtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// UpdateEnv is the environment variable to rewrite the golden files
+// instead of comparing with them:
+//
+//	PBTEST_UPDATE=1 go test ./...
+const UpdateEnv = "PBTEST_UPDATE"
+
+// Golden compares got with the content of the golden file, and
+// reports the difference as a test failure.
+//
+// The file is (re)written if the environment variable PBTEST_UPDATE
+// is set.
+func Golden(tb testing.TB, file, got string) {
+	tb.Helper()
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			tb.Fatalf("pbtest: %v", err)
+		}
+		if err := os.WriteFile(file, []byte(got), 0o644); err != nil {
+			tb.Fatalf("pbtest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		tb.Fatalf("pbtest: %v (run with %s=1 to create it)", err, UpdateEnv)
+	}
+	if string(want) != got {
+		tb.Errorf("pbtest: screen mismatched with %s\n--- want:\n%s\n--- got:\n%s", file, want, got)
+	}
+}
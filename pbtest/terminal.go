@@ -0,0 +1,425 @@
+// Package pbtest provides an in-memory terminal emulator for
+// testing what the progress bars look like.
+//
+// A Terminal interprets the cursor movements, erasing and color
+// escape sequences produced by color.RowsBlock, color.Up and the
+// steppers/spinners, so a test can assert the final screen grid
+// or the history of frames:
+//
+//	term := pbtest.New(80, 24)
+//	mpb := progressbar.NewV2(
+//		progressbar.WithOutput(term),
+//		progressbar.WithRenderMode(progressbar.RenderTTY),
+//	)
+//	...
+//	mpb.Run(ctx)
+//	pbtest.Golden(t, "testdata/download.golden", term.Screen())
+package pbtest
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/hedzr/progressbar/v2/internal/cellwidth"
+)
+
+// Terminal is an in-memory terminal emulator, which implements
+// io.Writer.
+type Terminal struct {
+	width, height int
+
+	grid       [][]string // cells, "" is the right half of a wide char
+	x, y       int
+	lastX      int // the cell written last, for appending the
+	lastY      int // zero-width chars of a grapheme cluster
+	joinNext   bool
+	savedX     int
+	savedY     int
+	wrapNext   bool // deferred autowrap at the last column
+	dirty      bool // printed something since the last frame
+	scrollback []string
+	frames     []string
+	pending    []byte // incomplete sequence of the last Write
+
+	cursorHidden bool
+	title        string
+	titles       []string // stack of pushed titles
+	progress     Progress
+
+	mu sync.Mutex
+}
+
+// Progress is the taskbar progress state set by the sequence
+// ESC ] 9 ; 4 ; st ; pr BEL.
+type Progress struct {
+	State int // 0: none, 1: normal, 2: error, 3: indeterminate, 4: paused
+	Value int // 0..100
+}
+
+// New creates a terminal with the given size.
+func New(width, height int) *Terminal {
+	t := &Terminal{width: width, height: height}
+	t.grid = make([][]string, height)
+	for i := range t.grid {
+		t.grid[i] = t.blankLine()
+	}
+	return t
+}
+
+// Fd returns an invalid file descriptor. It allows Terminal to be
+// used as a color.Writer.
+func (t *Terminal) Fd() uintptr { return ^uintptr(0) }
+
+// Size returns the width and height of the terminal.
+func (t *Terminal) Size() (width, height int) { return t.width, t.height }
+
+// Write interprets data as the terminal does.
+func (t *Terminal) Write(data []byte) (n int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n = len(data)
+	if len(t.pending) > 0 {
+		data = append(t.pending, data...)
+		t.pending = nil
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == 0x1b:
+			l := t.escape(data[i:])
+			if l == 0 { // incomplete, waiting for next Write
+				t.pending = append([]byte(nil), data[i:]...)
+				return
+			}
+			i += l
+			continue
+		case c == '\n':
+			t.lineFeed()
+			t.x, t.wrapNext = 0, false // like a tty with ONLCR
+		case c == '\r':
+			t.x, t.wrapNext = 0, false
+		case c == '\b':
+			if t.x > 0 {
+				t.x--
+			}
+			t.wrapNext = false
+		case c == '\t':
+			t.x = min((t.x/8+1)*8, t.width-1)
+		case c < 0x20 || c == 0x7f:
+			// other control chars are ignored
+		default:
+			if !utf8.FullRune(data[i:]) {
+				t.pending = append([]byte(nil), data[i:]...)
+				return
+			}
+			r, size := utf8.DecodeRune(data[i:])
+			t.put(r)
+			i += size
+			continue
+		}
+		i++
+	}
+	return
+}
+
+// Screen returns the content of the screen grid. The trailing
+// spaces of each line and the trailing blank lines are trimmed.
+func (t *Terminal) Screen() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.screen()
+}
+
+// Lines returns the lines of the screen grid, the trailing spaces
+// of each line are trimmed.
+func (t *Terminal) Lines() (lines []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, row := range t.grid {
+		lines = append(lines, rowString(row))
+	}
+	return
+}
+
+// Frames returns the history of the frames.
+//
+// A frame is recorded once the cursor moves up after something
+// printed, that is, the renderer starts repainting the live area.
+// The current screen is appended as the last frame if it differs
+// from the recorded one.
+func (t *Terminal) Frames() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	frames := append([]string(nil), t.frames...)
+	if cur := t.screen(); len(frames) == 0 || frames[len(frames)-1] != cur {
+		frames = append(frames, cur)
+	}
+	return frames
+}
+
+// Scrollback returns the lines scrolled out of the top of screen.
+func (t *Terminal) Scrollback() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.scrollback...)
+}
+
+// Cursor returns the cursor position (0-based) and visibility.
+func (t *Terminal) Cursor() (x, y int, visible bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.x, t.y, !t.cursorHidden
+}
+
+// Title returns the window title set by OSC 0 or OSC 2.
+func (t *Terminal) Title() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.title
+}
+
+// Progress returns the taskbar progress state set by OSC 9;4.
+func (t *Terminal) Progress() Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.progress
+}
+
+func (t *Terminal) screen() string {
+	lines := make([]string, 0, len(t.grid))
+	for _, row := range t.grid {
+		lines = append(lines, rowString(row))
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func rowString(row []string) string {
+	return strings.TrimRight(strings.Join(row, ""), " ")
+}
+
+func (t *Terminal) blankLine() []string {
+	line := make([]string, t.width)
+	for i := range line {
+		line[i] = " "
+	}
+	return line
+}
+
+// put writes r at the cursor. A wide char occupies two cells, and
+// the zero-width chars are appended to the last cell as a part of
+// the grapheme cluster.
+func (t *Terminal) put(r rune) {
+	w := cellwidth.RuneWidth(r)
+	if (w == 0 || t.joinNext) && t.grid[t.lastY][t.lastX] != " " {
+		t.joinNext = r == '\u200d'
+		t.grid[t.lastY][t.lastX] += string(r)
+		if r == '\ufe0f' && t.lastX+1 < t.width && t.grid[t.lastY][t.lastX+1] != "" {
+			t.advance(1) // emoji presentation widens the char
+			t.grid[t.lastY][t.lastX+1] = ""
+		}
+		return
+	}
+	if w == 0 {
+		return
+	}
+
+	if t.wrapNext || t.x+w > t.width {
+		t.lineFeed()
+		t.x, t.wrapNext = 0, false
+	}
+	row := t.grid[t.y]
+	if row[t.x] == "" && t.x > 0 {
+		row[t.x-1] = " " // overwrite the right half of a wide char
+	}
+	if end := t.x + w; end < t.width && row[end] == "" {
+		row[end] = " " // overwrite the left half of a wide char
+	}
+	row[t.x] = string(r)
+	if w == 2 {
+		row[t.x+1] = ""
+	}
+	t.lastX, t.lastY = t.x, t.y
+	t.dirty = true
+	t.advance(w)
+}
+
+func (t *Terminal) advance(w int) {
+	if t.x+w >= t.width {
+		t.x, t.wrapNext = t.width-1, true
+	} else {
+		t.x += w
+	}
+}
+
+func (t *Terminal) lineFeed() {
+	if t.y+1 < t.height {
+		t.y++
+		return
+	}
+	t.scrollback = append(t.scrollback, rowString(t.grid[0]))
+	copy(t.grid, t.grid[1:])
+	t.lastY = max(t.lastY-1, 0)
+	t.grid[t.height-1] = t.blankLine()
+}
+
+func (t *Terminal) frame() {
+	if t.dirty {
+		t.frames = append(t.frames, t.screen())
+		t.dirty = false
+	}
+}
+
+// escape interprets the escape sequence at the beginning of data,
+// and returns its length, or 0 if it's incomplete.
+func (t *Terminal) escape(data []byte) int {
+	if len(data) < 2 {
+		return 0
+	}
+	switch data[1] {
+	case '[':
+		for i := 2; i < len(data); i++ {
+			if c := data[i]; c >= 0x40 && c <= 0x7e {
+				t.csi(string(data[2:i]), c)
+				return i + 1
+			}
+		}
+		return 0
+	case ']':
+		for i := 2; i < len(data); i++ {
+			if data[i] == '\a' {
+				t.osc(string(data[2:i]))
+				return i + 1
+			}
+			if data[i] == 0x1b && i+1 < len(data) && data[i+1] == '\\' {
+				t.osc(string(data[2:i]))
+				return i + 2
+			}
+		}
+		return 0
+	case '7':
+		t.savedX, t.savedY = t.x, t.y
+	case '8':
+		t.x, t.y = t.savedX, t.savedY
+	}
+	return 2
+}
+
+func (t *Terminal) csi(params string, final byte) {
+	private := strings.HasPrefix(params, "?")
+	params = strings.TrimPrefix(params, "?")
+
+	var args []int
+	for _, p := range strings.Split(params, ";") {
+		v, _ := strconv.Atoi(p)
+		args = append(args, v)
+	}
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	t.wrapNext, t.joinNext = false, false
+	switch final {
+	case 'A': // cursor up
+		t.frame()
+		t.y = max(t.y-arg(0, 1), 0)
+	case 'B': // cursor down
+		t.y = min(t.y+arg(0, 1), t.height-1)
+	case 'C': // cursor forward
+		t.x = min(t.x+arg(0, 1), t.width-1)
+	case 'D': // cursor back
+		t.x = max(t.x-arg(0, 1), 0)
+	case 'E': // cursor next line
+		t.y, t.x = min(t.y+arg(0, 1), t.height-1), 0
+	case 'F': // cursor previous line
+		t.frame()
+		t.y, t.x = max(t.y-arg(0, 1), 0), 0
+	case 'G': // cursor horizontal absolute
+		t.x = min(max(arg(0, 1)-1, 0), t.width-1)
+	case 'H', 'f': // cursor position
+		t.y = min(max(arg(0, 1)-1, 0), t.height-1)
+		t.x = min(max(arg(1, 1)-1, 0), t.width-1)
+	case 'J': // erase in display
+		t.eraseDisplay(arg(0, 0))
+	case 'K': // erase in line
+		t.eraseLine(arg(0, 0))
+	case 's':
+		t.savedX, t.savedY = t.x, t.y
+	case 'u':
+		t.x, t.y = t.savedX, t.savedY
+	case 'h', 'l':
+		if private && arg(0, 0) == 25 {
+			t.cursorHidden = final == 'l'
+		}
+	case 't': // window manipulation, 22: push title, 23: pop title
+		switch arg(0, 0) {
+		case 22:
+			t.titles = append(t.titles, t.title)
+		case 23:
+			if n := len(t.titles); n > 0 {
+				t.title, t.titles = t.titles[n-1], t.titles[:n-1]
+			}
+		}
+	}
+	// 'm' (SGR) and the others are ignored.
+}
+
+func (t *Terminal) eraseLine(mode int) {
+	row := t.grid[t.y]
+	from, to := 0, t.width
+	switch mode {
+	case 0:
+		from = t.x
+	case 1:
+		to = t.x + 1
+	}
+	for i := from; i < to && i < t.width; i++ {
+		row[i] = " "
+	}
+}
+
+func (t *Terminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.eraseLine(0)
+		for y := t.y + 1; y < t.height; y++ {
+			t.grid[y] = t.blankLine()
+		}
+	case 1:
+		t.eraseLine(1)
+		for y := 0; y < t.y; y++ {
+			t.grid[y] = t.blankLine()
+		}
+	default:
+		for y := range t.grid {
+			t.grid[y] = t.blankLine()
+		}
+	}
+}
+
+func (t *Terminal) osc(body string) {
+	ps, pt, _ := strings.Cut(body, ";")
+	switch ps {
+	case "0", "2":
+		t.title = pt
+	case "9":
+		// 9;4;st;pr
+		parts := strings.Split(pt, ";")
+		if len(parts) >= 2 && parts[0] == "4" {
+			t.progress.State, _ = strconv.Atoi(parts[1])
+			t.progress.Value = 0
+			if len(parts) >= 3 {
+				t.progress.Value, _ = strconv.Atoi(parts[2])
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package pbtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTerminal(t *testing.T) {
+	term := New(20, 4)
+	fmt.Fprint(term, "\x1b[?25lhello \x1b[32mworld\x1b[0m\n")
+	fmt.Fprint(term, "line 2\nline 3")
+	fmt.Fprint(term, "\r\x1b[1A\x1b[2Kredrawn\x1b[1B\r\x1b[0K3")
+
+	if got, want := term.Screen(), "hello world\nredrawn\n3"; got != want {
+		t.Fatalf("expect screen %q, but got %q", want, got)
+	}
+	if _, _, visible := term.Cursor(); visible {
+		t.Fatal("expect cursor hidden")
+	}
+
+	frames := term.Frames()
+	if len(frames) != 2 || frames[0] != "hello world\nline 2\nline 3" {
+		t.Fatalf("unexpected frames %q", frames)
+	}
+}
+
+func TestTerminalScrollAndWrap(t *testing.T) {
+	term := New(5, 2)
+	fmt.Fprint(term, "12345678\nabc\n")
+	if got, want := term.Screen(), "abc"; got != want {
+		t.Fatalf("expect screen %q, but got %q", want, got)
+	}
+	if got, want := strings.Join(term.Scrollback(), "|"), "12345|678"; got != want {
+		t.Fatalf("expect scrollback %q, but got %q", want, got)
+	}
+}
+
+func TestTerminalSplitWrites(t *testing.T) {
+	term := New(10, 2)
+	for _, s := range []string{"a\x1b", "[3", "1mb\xe2\x96", "\x88\x1b]0;ti", "tle\a"} {
+		_, _ = term.Write([]byte(s))
+	}
+	if got, want := term.Screen(), "ab█"; got != want {
+		t.Fatalf("expect screen %q, but got %q", want, got)
+	}
+	if got := term.Title(); got != "title" {
+		t.Fatalf("expect title %q, but got %q", "title", got)
+	}
+}
+
+func TestTerminalWideChars(t *testing.T) {
+	term := New(10, 4)
+	fmt.Fprint(term, "中文ab\n")
+	fmt.Fprint(term, "e\u0301\U0001F469\u200D\U0001F4BBx\n") // é👩‍💻x
+	fmt.Fprint(term, "123456789中")
+	want := "中文ab|e\u0301\U0001F469\u200D\U0001F4BBx|123456789|中"
+	if got := strings.Join(term.Lines(), "|"); got != want {
+		t.Fatalf("expect lines %q, but got %q", want, got)
+	}
+
+	fmt.Fprint(term, "\x1b[1;2Hx")
+	if got := term.Lines()[0]; got != " x文ab" {
+		t.Fatalf("expect a split wide char erased, but got %q", got)
+	}
+}
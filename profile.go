@@ -0,0 +1,259 @@
+package progressbar
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hedzr/is/states"
+	"github.com/hedzr/is/term/color"
+)
+
+// ColorLevel is how many colors the terminal can display.
+type ColorLevel int
+
+const (
+	ColorNone      ColorLevel = iota // no color, escape sequences are stripped
+	ColorBasic                       // the 16 ANSI colors
+	Color256                         // the xterm 256-color palette
+	ColorTrueColor                   // 24-bit RGB colors
+)
+
+func (l ColorLevel) String() string {
+	switch l {
+	case ColorNone:
+		return "none"
+	case ColorBasic:
+		return "16"
+	case Color256:
+		return "256"
+	default:
+		return "truecolor"
+	}
+}
+
+// Profile describes what the terminal can display. The steppers
+// and spinners degrade their colors to Colors, and fall back to
+// the ASCII glyphs if Unicode is false.
+type Profile struct {
+	Colors  ColorLevel
+	Unicode bool
+}
+
+var (
+	// ProfileASCII is a profile for the dumb terminals and log
+	// files: no color, ASCII glyphs only.
+	ProfileASCII = Profile{Colors: ColorNone}
+	// ProfileTrueColor is a profile for the modern terminals.
+	ProfileTrueColor = Profile{Colors: ColorTrueColor, Unicode: true}
+)
+
+var (
+	detectOnce      sync.Once
+	detectedProfile Profile
+)
+
+// DetectProfile returns the profile of the current terminal
+// detected from the environment:
+//
+//   - NO_COLOR (or the no-color mode of hedzr/is) disables the colors.
+//   - COLORTERM=truecolor|24bit, or a TERM such as xterm-direct,
+//     enables 24-bit colors; a TERM such as xterm-256color enables
+//     256 colors; TERM=dumb disables the colors.
+//   - A locale (LC_ALL, LC_CTYPE or LANG) other than UTF-8 disables
+//     the Unicode glyphs.
+//
+// The result is detected once and cached.
+func DetectProfile() Profile {
+	detectOnce.Do(func() {
+		detectedProfile = detectProfile(os.Getenv)
+		if states.Env().IsNoColorMode() {
+			detectedProfile.Colors = ColorNone
+		}
+	})
+	return detectedProfile
+}
+
+func detectProfile(getenv func(key string) string) (p Profile) {
+	p.Unicode = unicodeLocale(getenv)
+
+	term := strings.ToLower(getenv("TERM"))
+	colorterm := strings.ToLower(getenv("COLORTERM"))
+	switch {
+	case getenv("NO_COLOR") != "", term == "dumb":
+		p.Colors = ColorNone
+	case colorterm == "truecolor", colorterm == "24bit",
+		strings.Contains(term, "truecolor"), strings.Contains(term, "24bit"), strings.HasSuffix(term, "-direct"):
+		p.Colors = ColorTrueColor
+	case strings.Contains(term, "256color"):
+		p.Colors = Color256
+	case term == "" && runtime.GOOS == "windows" && getenv("WT_SESSION") != "":
+		p.Colors = ColorTrueColor // Windows Terminal
+	default:
+		p.Colors = ColorBasic
+	}
+	return
+}
+
+// unicodeLocale reports whether the locale allows the Unicode
+// glyphs. An unset locale is assumed to be UTF-8.
+func unicodeLocale(getenv func(key string) string) bool {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := getenv(key); v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return true
+}
+
+// translator returns the translator for the <font> tags of the
+// schema, which strips the tags if the colors are disabled.
+func (p Profile) translator() color.Translator {
+	if p.Colors == ColorNone {
+		return color.GetCPTNC()
+	}
+	return color.GetCPT()
+}
+
+// adapt degrades clr to the nearest color which the profile can
+// display. fallback is used instead of a computed one on a 16
+// colors terminal if it isn't nil.
+func (p Profile) adapt(clr, fallback color.Color) color.Color {
+	if clr == nil || p.Colors == ColorTrueColor {
+		return clr
+	}
+	params := sgrParams(clr)
+	if len(params) < 3 || (params[0] != 38 && params[0] != 48) {
+		return clr // a 16-color or a style
+	}
+	bg := params[0] == 48
+
+	var r, g, b int
+	switch {
+	case params[1] == 2 && len(params) == 5:
+		if p.Colors == Color256 {
+			return color.NewColor256(byte(rgbTo256(params[2], params[3], params[4])), bg)
+		}
+		r, g, b = params[2], params[3], params[4]
+	case params[1] == 5:
+		if p.Colors == Color256 {
+			return clr
+		}
+		r, g, b = xterm256ToRGB(params[2])
+	default:
+		return clr
+	}
+	if fallback != nil {
+		return fallback
+	}
+	c := rgbTo16(r, g, b)
+	if bg {
+		c += 10
+	}
+	return c
+}
+
+func (p Profile) ellipsis() string {
+	if p.Unicode {
+		return ellipsis
+	}
+	return "..."
+}
+
+// sgrParams parses the parameters of the SGR sequence of clr,
+// such as [38 2 173 147 77] for "\x1b[38;2;173;147;77m".
+func sgrParams(clr color.Color) (params []int) {
+	s := clr.Color()
+	if !strings.HasPrefix(s, "\x1b[") || !strings.HasSuffix(s, "m") {
+		return
+	}
+	for _, f := range strings.Split(s[2:len(s)-1], ";") {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return nil
+		}
+		params = append(params, v)
+	}
+	return
+}
+
+// cube6 is the levels of the 6x6x6 color cube of xterm.
+var cube6 = [6]int{0, 95, 135, 175, 215, 255}
+
+func rgbTo256(r, g, b int) int {
+	idx := func(v int) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (v - 35) / 40
+	}
+	ri, gi, bi := idx(r), idx(g), idx(b)
+	cube := 16 + 36*ri + 6*gi + bi
+
+	// the grayscale ramp might be closer
+	avg := (r + g + b) / 3
+	gray := 232 + max(min((avg-3)/10, 23), 0)
+	gv := 8 + (gray-232)*10
+	if distance(r, g, b, gv, gv, gv) < distance(r, g, b, cube6[ri], cube6[gi], cube6[bi]) {
+		return gray
+	}
+	return cube
+}
+
+func xterm256ToRGB(n int) (r, g, b int) {
+	switch {
+	case n < 16:
+		c := ansi16[n%16]
+		return c[0], c[1], c[2]
+	case n < 232:
+		n -= 16
+		return cube6[n/36], cube6[n/6%6], cube6[n%6]
+	default:
+		v := 8 + (n-232)*10
+		return v, v, v
+	}
+}
+
+// ansi16 is the typical palette of the 16 ANSI colors.
+var ansi16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+func rgbTo16(r, g, b int) color.Color16 {
+	best, bestD := 0, -1
+	for i, c := range ansi16 {
+		if d := distance(r, g, b, c[0], c[1], c[2]); bestD < 0 || d < bestD {
+			best, bestD = i, d
+		}
+	}
+	if best < 8 {
+		return color.FgBlack + color.Color16(best)
+	}
+	return color.FgLightBlack + color.Color16(best-8)
+}
+
+func distance(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return dr*dr + dg*dg + db*db
+}
+
+// isASCII reports whether all of the strings are ASCII.
+func isASCII(ss ...string) bool {
+	for _, s := range ss {
+		for i := 0; i < len(s); i++ {
+			if s[i] >= 0x80 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package progressbar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hedzr/is/term/color"
+)
+
+func TestDetectProfile(t *testing.T) {
+	for _, cs := range []struct {
+		env    map[string]string
+		expect Profile
+	}{
+		{map[string]string{}, Profile{Colors: ColorBasic, Unicode: true}},
+		{map[string]string{"TERM": "xterm-256color", "LANG": "en_US.UTF-8"}, Profile{Colors: Color256, Unicode: true}},
+		{map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, Profile{Colors: ColorTrueColor, Unicode: true}},
+		{map[string]string{"TERM": "xterm-direct"}, Profile{Colors: ColorTrueColor, Unicode: true}},
+		{map[string]string{"TERM": "dumb", "LANG": "en_US.UTF-8"}, Profile{Colors: ColorNone, Unicode: true}},
+		{map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, Profile{Colors: ColorNone, Unicode: true}},
+		{map[string]string{"TERM": "xterm", "LANG": "C"}, Profile{Colors: ColorBasic}},
+		{map[string]string{"TERM": "xterm", "LC_ALL": "POSIX", "LANG": "en_US.UTF-8"}, Profile{Colors: ColorBasic}},
+		{map[string]string{"TERM": "xterm", "LC_CTYPE": "zh_CN.utf8"}, Profile{Colors: ColorBasic, Unicode: true}},
+	} {
+		got := detectProfile(func(key string) string { return cs.env[key] })
+		if got != cs.expect {
+			t.Fatalf("%v: expect %+v, but got %+v", cs.env, cs.expect, got)
+		}
+	}
+}
+
+func TestProfileAdapt(t *testing.T) {
+	rgb := color.NewColor16m(173, 147, 77, false)
+	for _, cs := range []struct {
+		profile  Profile
+		clr      color.Color
+		fallback color.Color
+		expect   string
+	}{
+		{ProfileTrueColor, rgb, nil, "\x1b[38;2;173;147;77m"},
+		{Profile{Colors: Color256}, rgb, nil, "\x1b[38;5;137m"},
+		{Profile{Colors: ColorBasic}, rgb, nil, "\x1b[90m"},
+		{Profile{Colors: ColorBasic}, rgb, color.FgYellow, "\x1b[33m"},
+		{Profile{Colors: ColorBasic}, color.NewColor16m(250, 10, 10, true), nil, "\x1b[101m"},
+		{Profile{Colors: ColorBasic}, color.NewColor256(21, false), nil, "\x1b[34m"},
+		{Profile{Colors: ColorBasic}, color.FgLightCyan, nil, "\x1b[96m"},
+		{Profile{Colors: Color256}, color.NewColor16m(128, 128, 128, false), nil, "\x1b[38;5;244m"},
+	} {
+		if got := cs.profile.adapt(cs.clr, cs.fallback).Color(); got != cs.expect {
+			t.Fatalf("%v: adapt %q, expect %q, but got %q", cs.profile.Colors, cs.clr.Color(), cs.expect, got)
+		}
+	}
+}
+
+func TestProfileASCII(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tsk := &TaskBar{Name: "a-very-long-title", max: 100, progress: 45, startTime: time.Now()}
+	tsk.stepper = steppers[0].clone(
+		WithStepperProfile(ProfileASCII),
+		WithStepperSchema(`<font color="green">{{.Title}}</font> {{.Bar}} {{.Percent}}`),
+		WithStepperWidth(10), WithStepperTitleMaxWidth(8), WithStepperTailSpace(-1),
+	)
+	got := tsk.String()
+	if strings.Contains(got, "\x1b") || !isASCII(got) {
+		t.Fatalf("expect no escapes and ASCII only, but got %q", got)
+	}
+	if expect := "a-ver... +++++-----  45.0%"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+
+	tsk.stepper = spinners[11].clone(WithStepperProfile(ProfileASCII), WithStepperSchema(`{{.Bar}}`), WithStepperTailSpace(-1))
+	for range 8 {
+		if got := strings.TrimSpace(tsk.String()); !strings.Contains(`|/-\`, got) {
+			t.Fatalf("expect an ASCII spinner frame, but got %q", got)
+		}
+	}
+}
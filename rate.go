@@ -0,0 +1,118 @@
+package progressbar
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// speedWindow is the time constant of the smoothed speed: a sample
+// weighs 1/e after speedWindow.
+const speedWindow = 2 * time.Second
+
+// sampleInterval is the minimal interval between two samples, so the
+// speeds don't depend on how often the bars are repainted.
+const sampleInterval = 100 * time.Millisecond
+
+// rateEstimator estimates the speed of a bar by an EWMA of the
+// samples taken once per frame, see sampleRates. The clock is the
+// elapsed time of the bar, so a bar not started yet or completed
+// doesn't change it.
+type rateEstimator struct {
+	mu      sync.Mutex
+	sampled bool
+	base    int64         // the position at the first sample, or at SetInitialValue
+	baseAt  time.Duration // the elapsed time at base
+	pos     int64         // the position at the last sample
+	at      time.Duration // the elapsed time at the last sample
+	rate    float64       // the smoothed speed, units per second
+	inst    float64       // the speed within the last sample interval
+}
+
+// estimator is implemented by the bars which estimate their speed.
+type estimator interface {
+	estimator() *rateEstimator
+}
+
+// reset drops the history, the next sample becomes the base. It's
+// called by SetInitialValue so that the resumed part of a download
+// doesn't count into the speed.
+func (r *rateEstimator) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sampled, r.rate, r.inst = false, 0, 0
+}
+
+// sample takes the position pos at the elapsed time. It's ignored
+// if the last sample is taken within sampleInterval.
+func (r *rateEstimator) sample(elapsed time.Duration, pos int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.sampled {
+		r.sampled = true
+		r.base, r.baseAt, r.pos, r.at = pos, elapsed, pos, elapsed
+		return
+	}
+	if dt := elapsed - r.at; dt >= sampleInterval {
+		r.inst = float64(pos-r.pos) / dt.Seconds()
+		if r.at == r.baseAt {
+			r.rate = r.inst
+		} else {
+			alpha := 1 - math.Exp(-dt.Seconds()/speedWindow.Seconds())
+			r.rate += alpha * (r.inst - r.rate)
+		}
+		r.pos, r.at = pos, elapsed
+	}
+}
+
+// estimates returns the smoothed speed, the speed within the last
+// sample interval and the average speed since the base. ok is false
+// if there are not enough samples yet.
+func (r *rateEstimator) estimates() (rate, inst, avg float64, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	span := (r.at - r.baseAt).Seconds()
+	if !r.sampled || span <= 0 {
+		return
+	}
+	return r.rate, r.inst, float64(r.pos-r.base) / span, true
+}
+
+// sampleRates samples the speeds of bars, once per frame before the
+// bars are drawn.
+func sampleRates[B MiniResizeableBar](bars []B) {
+	for _, bar := range bars {
+		if e, ok := any(bar).(estimator); ok {
+			_, _, pos := bar.State()
+			e.estimator().sample(bar.Dur(), pos)
+		}
+	}
+}
+
+// setEstimates fills the speeds in the unit u and the estimated
+// time to go of the bar into data.
+func setEstimates(data *SchemaData, st barSnapshot, u Unit) {
+	data.InstantSpeed = u.speed(st.instSpeed)
+	data.AvgSpeed = u.speed(st.avgSpeed)
+	data.Remaining = st.remaining
+	data.ETA = "-"
+	if st.remaining >= 0 {
+		data.ETA = durfmt(st.remaining)
+		data.FinishAt = time.Now().Add(st.remaining)
+	}
+}
+
+// eta returns the time to go from pos to max at rate, or -1 if it's
+// unknown.
+func eta(min, max, pos int64, rate float64) time.Duration {
+	switch {
+	case indeterminate(min, max):
+		return -1
+	case pos >= max:
+		return 0
+	case rate <= 0:
+		return -1
+	}
+	return time.Duration(float64(max-pos) / rate * float64(time.Second))
+}
@@ -0,0 +1,78 @@
+package progressbar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateEstimator(t *testing.T) {
+	var r rateEstimator
+	r.sample(0, 0)
+	if _, _, _, ok := r.estimates(); ok {
+		t.Fatal("expect no speed from a single sample")
+	}
+	r.sample(time.Second, 100)
+	rate, _, avg, _ := r.estimates()
+	if rate != 100 || avg != 100 {
+		t.Fatalf("expect 100/s at first, but got %v, %v", rate, avg)
+	}
+
+	// the samples within sampleInterval are ignored, and reading the
+	// estimates changes nothing.
+	r.sample(time.Second+sampleInterval/2, 200)
+	for range 3 {
+		if rate, inst, avg, _ := r.estimates(); rate != 100 || inst != 100 || avg != 100 {
+			t.Fatalf("expect 100/s unchanged, but got %v, %v, %v", rate, inst, avg)
+		}
+	}
+
+	// stalled for 4s, the smoothed speed decays but the average
+	// since the start stays higher.
+	for i := 2; i <= 5; i++ {
+		r.sample(time.Duration(i)*time.Second, 100)
+	}
+	if rate, _, avg, _ = r.estimates(); rate > 15 || avg != 20 {
+		t.Fatalf("expect the smoothed speed decayed after a stall, but got %v, %v", rate, avg)
+	}
+
+	// resumed from 1000, which doesn't count into the speed.
+	r.reset()
+	r.sample(6*time.Second, 1000)
+	r.sample(7*time.Second, 1050)
+	if rate, inst, avg, _ := r.estimates(); rate != 50 || inst != 50 || avg != 50 {
+		t.Fatalf("expect 50/s after reset, but got %v, %v, %v", rate, inst, avg)
+	}
+}
+
+func TestETA(t *testing.T) {
+	for _, c := range []struct {
+		min, max, pos int64
+		rate          float64
+		expect        time.Duration
+	}{
+		{0, 100, 50, 10, 5 * time.Second},
+		{0, 100, 100, 0, 0},
+		{0, 100, 50, 0, -1},
+		{0, UnknownTotal, 50, 10, -1},
+	} {
+		if got := eta(c.min, c.max, c.pos, c.rate); got != c.expect {
+			t.Fatalf("eta(%d, %d, %d, %v): expect %v, but got %v", c.min, c.max, c.pos, c.rate, c.expect, got)
+		}
+	}
+}
+
+func TestSchemaETA(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tsk := &TaskBar{Name: "x", max: 100, progress: 0, startTime: time.Now().Add(-time.Second)}
+	WithTaskBarStepper(0, WithStepperProfile(ProfileASCII),
+		WithStepperSchema(`{{.ETA}}|{{if .FinishAt.IsZero}}-{{else}}t{{end}}`))(tsk)
+	if got, expect := screenOf(tsk.String()), "-|-"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+
+	tsk.progress = 100
+	if got, expect := screenOf(tsk.String()), "0s|t"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+}
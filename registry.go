@@ -0,0 +1,139 @@
+package progressbar
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hedzr/is/term/color"
+)
+
+// StepperStyle describes the look of a stepper registered by
+// RegisterStepper.
+type StepperStyle struct {
+	Read      string // the glyph of the completed part
+	Unread    string // the glyph of the remaining part
+	LeftHalf  string // the glyph at the head, on a cell boundary
+	RightHalf string // the glyph at the head, in the middle of a cell
+	LeftCap   string // drawn before the bar, such as "["
+	RightCap  string // drawn after the bar, such as "]"
+
+	// Partials are the glyphs of a cell from the least to the fully
+	// filled, see WithStepperPartials. LeftHalf and RightHalf are
+	// ignored if it's not empty.
+	Partials []string
+
+	BaseColor      color.Color // the color of the head on a cell boundary, default is dark gray
+	HighlightColor color.Color // the color of the bar, default is yellow
+}
+
+var (
+	muRegistry   sync.RWMutex
+	stepperNames = make(map[string]int)
+	spinnerNames = make(map[string]int)
+)
+
+// RegisterStepper registers a stepper style by name, and returns
+// its index, which can be used with WithBarStepper and
+// WithTaskBarStepper as the built-in ones. The name can be used
+// with WithBarStepperNamed and WithTaskBarStepperNamed.
+//
+// Registering a name again replaces the style and keeps the index.
+func RegisterStepper(name string, style StepperStyle) (index int) {
+	s := &stepper{
+		read: style.Read, unread: style.Unread,
+		leftHalf: style.LeftHalf, rightHalf: style.RightHalf,
+		leftCap: style.LeftCap, rightCap: style.RightCap,
+		partials: append([]string(nil), style.Partials...),
+		clrBase:  style.BaseColor, clrHighlight: style.HighlightColor,
+	}
+	if s.clrBase == nil {
+		s.clrBase = color.FgDarkGray
+	}
+	if s.clrHighlight == nil {
+		s.clrHighlight = color.FgYellow
+	}
+
+	muRegistry.Lock()
+	defer muRegistry.Unlock()
+	index, ok := stepperNames[name]
+	if !ok {
+		index = len(steppers)
+		stepperNames[name] = index
+	}
+	steppers[index] = s
+	return
+}
+
+// RegisterSpinner registers a spinner by name, and returns its
+// index, which can be used with WithBarSpinner and
+// WithTaskBarSpinner as the built-in ones. The name can be used
+// with WithBarSpinnerNamed and WithTaskBarSpinnerNamed.
+//
+// Each frame is shown for interval. If interval is zero, the
+// default 100ms is used.
+//
+// Registering a name again replaces the spinner and keeps the
+// index.
+func RegisterSpinner(name string, frames []string, interval time.Duration) (index int) {
+	s := &spinner{chars: append([]string(nil), frames...), interval: interval}
+
+	muRegistry.Lock()
+	defer muRegistry.Unlock()
+	index, ok := spinnerNames[name]
+	if !ok {
+		index = len(spinners)
+		spinnerNames[name] = index
+	}
+	spinners[index] = s
+	return
+}
+
+// StepperIndex returns the index of a stepper registered by
+// RegisterStepper.
+func StepperIndex(name string) (index int, ok bool) {
+	muRegistry.RLock()
+	defer muRegistry.RUnlock()
+	index, ok = stepperNames[name]
+	return
+}
+
+// SpinnerIndex returns the index of a spinner registered by
+// RegisterSpinner.
+func SpinnerIndex(name string) (index int, ok bool) {
+	muRegistry.RLock()
+	defer muRegistry.RUnlock()
+	index, ok = spinnerNames[name]
+	return
+}
+
+func stepperAt(index int) (s *stepper, ok bool) {
+	muRegistry.RLock()
+	defer muRegistry.RUnlock()
+	s, ok = steppers[index]
+	return
+}
+
+func spinnerAt(index int) (s *spinner, ok bool) {
+	muRegistry.RLock()
+	defer muRegistry.RUnlock()
+	s, ok = spinners[index]
+	return
+}
+
+func stepperNamed(name string) (s *stepper, ok bool) {
+	muRegistry.RLock()
+	defer muRegistry.RUnlock()
+	if index, found := stepperNames[name]; found {
+		s, ok = steppers[index]
+	}
+	return
+}
+
+func spinnerNamed(name string) (s *spinner, ok bool) {
+	muRegistry.RLock()
+	defer muRegistry.RUnlock()
+	if index, found := spinnerNames[name]; found {
+		s, ok = spinners[index]
+	}
+	return
+}
@@ -0,0 +1,88 @@
+package progressbar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterStepper(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	style := StepperStyle{Read: "#", Unread: ".", LeftHalf: ".", RightHalf: "#", LeftCap: "[", RightCap: "]"}
+	idx := RegisterStepper("test-corp", style)
+	if idx != MaxSteppers()-1 || idx < 4 {
+		t.Fatalf("expect a new index after the built-in steppers, but got %d", idx)
+	}
+	if got, ok := StepperIndex("test-corp"); !ok || got != idx {
+		t.Fatalf("expect StepperIndex returns %d, but got %d, %v", idx, got, ok)
+	}
+	style.Unread = "-"
+	if again := RegisterStepper("test-corp", style); again != idx {
+		t.Fatalf("expect registering again keeps the index %d, but got %d", idx, again)
+	}
+
+	tsk := &TaskBar{Name: "x", max: 100, progress: 50, startTime: time.Now()}
+	WithTaskBarStepperNamed("test-corp",
+		WithStepperProfile(ProfileTrueColor), WithStepperSchema(`{{.Title}} {{.Bar}}`),
+		WithStepperWidth(AutoWidth), WithStepperTailSpace(-1),
+	)(tsk)
+	fixTermSize(t, 16, 24)
+	if got, expect := screenOf(tsk.String()), "x [######-----]"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+
+	pb := &pbar{}
+	WithBarStepperNamed("unknown")(pb)
+	if pb.stepper != nil {
+		t.Fatal("expect an unknown name ignored")
+	}
+}
+
+func TestRegisterSpinner(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	idx := RegisterSpinner("test-abc", []string{"a", "b", "c"}, time.Second)
+	if got, ok := SpinnerIndex("test-abc"); !ok || got != idx || idx != MaxSpinners()-1 {
+		t.Fatalf("expect SpinnerIndex returns %d, but got %d, %v", idx, got, ok)
+	}
+
+	tsk := &TaskBar{Name: "x", max: 100, startTime: time.Now().Add(-1050 * time.Millisecond)}
+	WithTaskBarSpinnerNamed("test-abc", WithStepperSchema(`{{.Bar}}`), WithStepperTailSpace(-1))(tsk)
+	for range 3 {
+		if got := strings.TrimSpace(tsk.String()); got != "b" {
+			t.Fatalf("expect the frame selected by elapsed time, but got %q", got)
+		}
+	}
+}
+
+func TestStepperPartials(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	bar := func(progress int64, p Profile, opts ...StepperOpt) string {
+		tsk := &TaskBar{Name: "x", max: 100, progress: progress, startTime: time.Now()}
+		WithTaskBarStepper(4, append([]StepperOpt{
+			WithStepperProfile(p), WithStepperSchema(`[{{.Bar}}]`),
+			WithStepperWidth(4), WithStepperTailSpace(-1),
+		}, opts...)...)(tsk)
+		return screenOf(tsk.String())
+	}
+
+	for _, c := range []struct {
+		progress int64
+		expect   string
+	}{
+		{0, "[    ]"}, {4, "[▏   ]"}, {10, "[▍   ]"}, {50, "[██  ]"}, {60, "[██▍ ]"}, {99, "[███▉]"}, {100, "[████]"},
+	} {
+		if got := bar(c.progress, ProfileTrueColor); got != c.expect {
+			t.Fatalf("%d%%: expect %q, but got %q", c.progress, c.expect, got)
+		}
+	}
+
+	if got, expect := bar(60, ProfileASCII), "[+++-]"; got != expect {
+		t.Fatalf("expect the ASCII fallback %q, but got %q", expect, got)
+	}
+	if got, expect := bar(60, ProfileASCII, WithStepperPartials(".", ":", "#")), "[++.-]"; got != expect {
+		t.Fatalf("expect the ASCII partials %q, but got %q", expect, got)
+	}
+}
@@ -0,0 +1,83 @@
+package progressbar
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/hedzr/is"
+	"github.com/hedzr/is/term/color"
+)
+
+// RenderMode selects how the progress bars are drawn onto the
+// output device.
+type RenderMode int
+
+const (
+	// RenderAuto draws animated bars if the output device is a
+	// terminal, or falls back to RenderPlain if not (redirected
+	// to a file, piped, running under CI, ...).
+	RenderAuto RenderMode = iota
+	// RenderTTY always draws animated bars with cursor movements.
+	RenderTTY
+	// RenderPlain prints line-oriented progress text periodically
+	// and a completion line for each task. No escape sequences
+	// for cursor movements will be emitted.
+	RenderPlain
+	// RenderJSON emits the progress as newline-delimited JSON
+	// events (see Event) instead of drawing bars, for the programs
+	// which consume the progress of a child process.
+	RenderJSON
+)
+
+func (m RenderMode) String() string {
+	switch m {
+	case RenderTTY:
+		return "tty"
+	case RenderPlain:
+		return "plain"
+	case RenderJSON:
+		return "json"
+	default:
+		return "auto"
+	}
+}
+
+// resolve returns the concrete mode for writing to out.
+func (m RenderMode) resolve(out io.Writer) RenderMode {
+	if m == RenderAuto {
+		if is.Tty(out) {
+			return RenderTTY
+		}
+		return RenderPlain
+	}
+	return m
+}
+
+const (
+	cursorHide = "\x1b[?25l"
+	cursorShow = "\x1b[?25h"
+	eraseBelow = "\x1b[0J"
+	eraseLine  = "\x1b[2K"
+)
+
+// cursorUp moves the cursor up n lines, nothing to do if n <= 0.
+func cursorUp(w io.Writer, n int) {
+	if n > 0 {
+		_, _ = io.WriteString(w, "\x1b["+strconv.Itoa(n)+"A")
+	}
+}
+
+// colorWriter wraps w as a color.Writer, which is required by
+// color.RowsBlock.
+func colorWriter(w io.Writer) color.Writer {
+	if cw, ok := w.(color.Writer); ok {
+		return cw
+	}
+	return fdWriter{w}
+}
+
+type fdWriter struct{ io.Writer }
+
+// Fd returns an invalid file descriptor since the underlying
+// writer isn't a file.
+func (fdWriter) Fd() uintptr { return ^uintptr(0) }
@@ -0,0 +1,165 @@
+package progressbar
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType is the type of an Event.
+type EventType string
+
+const (
+	EventAdded     EventType = "added"      // a task was added
+	EventStarted   EventType = "started"    // a task made its first progress
+	EventProgress  EventType = "progress"   // the position of a task changed
+	EventCompleted EventType = "completed"  // a task reached its upper bound
+	EventFailed    EventType = "failed"     // a task failed, see Event.Error
+	EventGroupDone EventType = "group_done" // all tasks of a group finished
+	EventLog       EventType = "log"        // a line printed by Println/Printf
+)
+
+// Event is a progress event emitted in RenderJSON mode, each one
+// is encoded as a JSON object in a line:
+//
+//	{"type":"progress","time":"...","group":"g","task":"t","min":0,"max":100,"pos":45,"percent":0.45,"speed":150,"elapsed":0.3}
+//
+// Min, Max, Pos, Percent, Speed and Elapsed are the same state
+// which feeds SchemaData. Speed is in units (bytes) per second,
+// and Elapsed is in seconds. If the total is unknown, Max is less
+// than Min (see UnknownTotal) and Percent is 0.
+type Event struct {
+	Type    EventType `json:"type"`
+	Time    time.Time `json:"time"`
+	Group   string    `json:"group,omitempty"`
+	Task    string    `json:"task,omitempty"`
+	Min     int64     `json:"min"`
+	Max     int64     `json:"max"`
+	Pos     int64     `json:"pos"`
+	Percent float64   `json:"percent"`
+	Speed   float64   `json:"speed"`
+	Elapsed float64   `json:"elapsed"`
+	Error   string    `json:"error,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+// jsonPrinter is the renderer used by RenderJSON.
+type jsonPrinter struct {
+	w      io.Writer
+	bars   map[MiniResizeableBar]*jsonState
+	groups map[string]bool // group_done emitted
+	mu     sync.Mutex
+}
+
+type jsonState struct {
+	pos      int64
+	started  bool
+	finished bool
+}
+
+func newJSONPrinter(w io.Writer) *jsonPrinter {
+	return &jsonPrinter{
+		w:      w,
+		bars:   make(map[MiniResizeableBar]*jsonState),
+		groups: make(map[string]bool),
+	}
+}
+
+// added emits an added event for bar.
+func (p *jsonPrinter) added(group string, bar MiniResizeableBar) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state(group, bar)
+}
+
+// update emits the events for the changes of bar since the last
+// call. finished is true once bar was completed or failed.
+func (p *jsonPrinter) update(group string, bar MiniResizeableBar) (finished bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st := p.state(group, bar)
+	if st.finished {
+		return true
+	}
+
+	snap := snapshotOf(bar)
+	if err := barErr(bar); err != nil {
+		st.finished = true
+		ev := p.barEvent(EventFailed, group, bar, snap)
+		ev.Error = err.Error()
+		p.emit(ev)
+		return true
+	}
+
+	completed := bar.Completed()
+	if !st.started && (snap.pos > snap.min || completed) {
+		st.started = true
+		p.emit(p.barEvent(EventStarted, group, bar, snap))
+	}
+	if snap.pos != st.pos {
+		st.pos = snap.pos
+		p.emit(p.barEvent(EventProgress, group, bar, snap))
+	}
+	if completed {
+		st.finished = true
+		p.emit(p.barEvent(EventCompleted, group, bar, snap))
+	}
+	return st.finished
+}
+
+// groupDone emits a group_done event once for group.
+func (p *jsonPrinter) groupDone(group string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.groups[group] {
+		p.groups[group] = true
+		p.emit(&Event{Type: EventGroupDone, Time: time.Now(), Group: group})
+	}
+}
+
+// log emits a log event, the printed lines must not break the
+// JSON stream.
+func (p *jsonPrinter) log(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.emit(&Event{Type: EventLog, Time: time.Now(), Message: strings.TrimSuffix(line, "\n")})
+}
+
+// state returns the state of bar, and emits an added event if bar
+// is unknown yet.
+func (p *jsonPrinter) state(group string, bar MiniResizeableBar) *jsonState {
+	st, ok := p.bars[bar]
+	if !ok {
+		snap := snapshotOf(bar)
+		st = &jsonState{pos: snap.pos}
+		p.bars[bar] = st
+		p.emit(p.barEvent(EventAdded, group, bar, snap))
+	}
+	return st
+}
+
+func (p *jsonPrinter) barEvent(typ EventType, group string, bar MiniResizeableBar, snap barSnapshot) *Event {
+	return &Event{
+		Type:    typ,
+		Time:    time.Now(),
+		Group:   group,
+		Task:    bar.Title(),
+		Min:     snap.min,
+		Max:     snap.max,
+		Pos:     snap.pos,
+		Percent: snap.percent,
+		Speed:   snap.speed,
+		Elapsed: snap.elapsed.Seconds(),
+	}
+}
+
+func (p *jsonPrinter) emit(ev *Event) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	_, _ = p.w.Write(append(data, '\n'))
+}
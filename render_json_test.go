@@ -0,0 +1,145 @@
+package progressbar
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeEvents(t *testing.T, data []byte) (events []Event) {
+	t.Helper()
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var ev Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", sc.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	return
+}
+
+// eventsOf returns the event types of a task (or of a group if
+// task is empty) in order.
+func eventsOf(events []Event, group, task string) (types []string) {
+	for _, ev := range events {
+		if ev.Group == group && ev.Task == task && ev.Type != EventProgress {
+			types = append(types, string(ev.Type))
+		}
+	}
+	return
+}
+
+func TestJSONPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	p := newJSONPrinter(&buf)
+	pb := &pbar{mpbar: &mpbar{}, max: 100, title: "x.tgz", stepper: steppers[0].clone(), startTime: time.Now()}
+	failed := &pbar{mpbar: &mpbar{}, max: 100, title: "y.tgz", stepper: steppers[0].clone(), startTime: time.Now()}
+
+	p.added("g", pb)
+	p.added("g", failed)
+	pb.read = 45
+	p.update("g", pb)
+	p.update("g", pb) // nothing changed
+	pb.read = 100
+	pb.invalidate()
+	if !p.update("g", pb) {
+		t.Fatal("expect the completed bar finished")
+	}
+	failed.err = errors.New("connection refused")
+	if !p.update("g", failed) {
+		t.Fatal("expect the failed bar finished")
+	}
+	p.groupDone("g")
+	p.groupDone("g")
+	p.log("hello\n")
+
+	events := decodeEvents(t, buf.Bytes())
+	var types []string
+	for _, ev := range events {
+		types = append(types, string(ev.Type))
+	}
+	expect := "added added started progress progress completed failed group_done log"
+	if got := strings.Join(types, " "); got != expect {
+		t.Fatalf("expect events %q, but got %q", expect, got)
+	}
+	if ev := events[3]; ev.Task != "x.tgz" || ev.Pos != 45 || ev.Max != 100 || ev.Percent != 0.45 {
+		t.Fatalf("unexpected progress event %+v", ev)
+	}
+	if ev := events[6]; ev.Task != "y.tgz" || ev.Error != "connection refused" {
+		t.Fatalf("unexpected failed event %+v", ev)
+	}
+	if ev := events[8]; ev.Message != "hello" {
+		t.Fatalf("unexpected log event %+v", ev)
+	}
+}
+
+func TestNewV2JSON(t *testing.T) {
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		if progress == 50 {
+			bar.Println("half")
+		}
+		return 25, nil
+	}
+
+	var buf bytes.Buffer
+	mpb := NewV2(WithOutput(&buf), WithRenderMode(RenderJSON), WithRefreshInterval(time.Millisecond))
+	_ = mpb.AddBar("Group 0", "Task #0", 0, 100, job)
+	_ = mpb.AddBar("Group 0", "Task #1", 0, 100, job)
+	_ = mpb.AddDownloadingBar("Group 1", "broken", &DownloadTask{Url: "://bad-url", Filename: t.TempDir() + "/broken"})
+	mpb.Run(context.Background())
+	mpb.Close()
+
+	events := decodeEvents(t, buf.Bytes())
+	for _, cs := range []struct {
+		group, task string
+		expect      string
+	}{
+		{"Group 0", "Task #0", "added started completed"},
+		{"Group 0", "Task #1", "added started completed"},
+		{"Group 0", "", "group_done"},
+		{"Group 1", "broken", "added failed"},
+		{"Group 1", "", "group_done"},
+		{"", "", "log log"}, // "half" of each task, the failure is logged to stderr
+	} {
+		if got := strings.Join(eventsOf(events, cs.group, cs.task), " "); got != cs.expect {
+			t.Fatalf("%s/%s: expect events %q, but got %q in %s", cs.group, cs.task, cs.expect, got, buf.String())
+		}
+	}
+}
+
+func TestMultiBarJSON(t *testing.T) {
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	mpb := NewGPB(
+		WithOutputDevice(&buf), WithMultiBarRenderMode(RenderJSON),
+		WithOnDone(func(mpb MultiPB) { close(done) }),
+	)
+	mpb.AddToGroup("Group", 100, "x.tgz")
+	mpb.AddToGroup("Group", 100, "y.tgz")
+	for i := range 2 {
+		pb := mpb.(*mpbar2).gb[0].bars[i]
+		_, _ = pb.Write(make([]byte, 100))
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the bars completed")
+	}
+	mpb.Close()
+
+	events := decodeEvents(t, buf.Bytes())
+	for _, task := range []string{"x.tgz", "y.tgz"} {
+		if got := strings.Join(eventsOf(events, "Group", task), " "); got != "added started completed" {
+			t.Fatalf("%s: unexpected events %q", task, got)
+		}
+	}
+	if got := strings.Join(eventsOf(events, "Group", ""), " "); got != "group_done" {
+		t.Fatalf("unexpected group events %q", got)
+	}
+}
@@ -0,0 +1,137 @@
+package progressbar
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// plainPrinter is the line-oriented renderer used by RenderPlain.
+//
+// For each bar it prints a line like
+//
+//	title: 45% (12MB/30MB)
+//
+// or, if the total is unknown,
+//
+//	title: 12MB
+//
+// periodically while the bar is progressing, and a completion
+// line once the bar is done:
+//
+//	title: done (30MB/30MB) in 3s
+//
+// or a failure line once the bar has failed or been cancelled:
+//
+//	title: failed (12MB/30MB): unexpected EOF
+type plainPrinter struct {
+	interval time.Duration
+	states   map[MiniResizeableBar]*plainState
+	titles   map[string]bool
+	mu       sync.Mutex
+}
+
+type plainState struct {
+	last     time.Time
+	percent  int
+	pos      int64 // for the bars whose total is unknown
+	finished bool
+}
+
+const plainPrintInterval = 2 * time.Second
+
+func newPlainPrinter() *plainPrinter {
+	return &plainPrinter{
+		interval: plainPrintInterval,
+		states:   make(map[MiniResizeableBar]*plainState),
+		titles:   make(map[string]bool),
+	}
+}
+
+// header prints a group title once.
+func (p *plainPrinter) header(w io.Writer, title string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if title == "" || p.titles[title] {
+		return
+	}
+	p.titles[title] = true
+	_, _ = io.WriteString(w, title+"\n")
+}
+
+// print writes a progress line for bar if it's time to do,
+// or the completion line if the bar has just been completed.
+func (p *plainPrinter) print(w io.Writer, bar MiniResizeableBar) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st, ok := p.states[bar]
+	if !ok {
+		st = &plainState{percent: -1, pos: -1}
+		p.states[bar] = st
+	}
+	if st.finished {
+		return
+	}
+
+	min, max, pos := bar.State()
+	var percent float64
+	if max > min {
+		percent = float64(pos-min) / float64(max-min)
+	}
+	if percent > 1 {
+		percent = 1
+	}
+
+	u := unitOf(bar)
+	read, total := u.format(float64(pos)), u.format(float64(max))
+
+	var sb strings.Builder
+	_, _ = sb.WriteString(bar.Title())
+	_, _ = sb.WriteString(": ")
+
+	if err := barErr(bar); err != nil {
+		st.finished = true
+		_, _ = sb.WriteString(stateOfErr(err).String())
+		_, _ = sb.WriteString(" (")
+		_, _ = sb.WriteString(read + "/" + total)
+		_, _ = sb.WriteString("): ")
+		_, _ = sb.WriteString(err.Error())
+		_, _ = sb.WriteString("\n")
+		_, _ = io.WriteString(w, sb.String())
+		return
+	}
+
+	if bar.Completed() {
+		st.finished = true
+		_, _ = sb.WriteString("done (")
+		_, _ = sb.WriteString(read + "/" + total)
+		_, _ = sb.WriteString(") in ")
+		_, _ = sb.WriteString(durfmt(bar.Dur()))
+		_, _ = sb.WriteString("\n")
+		_, _ = io.WriteString(w, sb.String())
+		return
+	}
+
+	now, pi := time.Now(), int(percent*100)
+	if indeterminate(min, max) {
+		if pos == st.pos || now.Sub(st.last) < p.interval {
+			return
+		}
+		st.last, st.pos = now, pos
+		_, _ = sb.WriteString(read + "\n")
+		_, _ = io.WriteString(w, sb.String())
+		return
+	}
+	if pi == st.percent || now.Sub(st.last) < p.interval {
+		return
+	}
+	st.last, st.percent = now, pi
+
+	_, _ = sb.WriteString(intfmt(int64(pi)))
+	_, _ = sb.WriteString("% (")
+	_, _ = sb.WriteString(read + "/" + total)
+	_, _ = sb.WriteString(")\n")
+	_, _ = io.WriteString(w, sb.String())
+}
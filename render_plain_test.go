@@ -0,0 +1,52 @@
+package progressbar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlainPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	p := newPlainPrinter()
+	pb := &pbar{mpbar: &mpbar{}, max: 100, title: "x.tgz", stepper: steppers[0].clone(), startTime: time.Now()}
+
+	p.header(&buf, "Group 1")
+	p.header(&buf, "Group 1")
+
+	pb.read = 45
+	p.print(&buf, pb)
+	pb.read = 46
+	p.print(&buf, pb) // within the interval, ignored
+
+	pb.read = 100
+	pb.invalidate()
+	p.print(&buf, pb)
+	p.print(&buf, pb) // completion line printed once
+
+	expect := []string{
+		"Group 1",
+		"x.tgz: 45% (45B/100B)",
+		"x.tgz: done (100B/100B) in 0s",
+	}
+	got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(got) != len(expect) {
+		t.Fatalf("expect %d lines but got %d: %q", len(expect), len(got), buf.String())
+	}
+	for i, line := range expect {
+		if got[i] != line {
+			t.Fatalf("line %d: expect %q but got %q", i, line, got[i])
+		}
+	}
+}
+
+func TestRenderModeResolve(t *testing.T) {
+	var buf bytes.Buffer
+	if m := RenderAuto.resolve(&buf); m != RenderPlain {
+		t.Fatalf("expect plain mode for non-tty writer, but got %v", m)
+	}
+	if m := RenderTTY.resolve(&buf); m != RenderTTY {
+		t.Fatalf("expect tty mode kept, but got %v", m)
+	}
+}
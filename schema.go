@@ -0,0 +1,220 @@
+package progressbar
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"text/template"
+	"text/template/parse"
+)
+
+// Schema is a schema validated by CompileSchema.
+type Schema struct {
+	text string
+	tmpl *template.Template
+}
+
+// CompileSchema parses schema with the built-in functions and funcs,
+// and checks the fields it refers to against SchemaData, so that a
+// typo such as {{.Titel}} or an unknown function is reported here
+// instead of by the bars. The fields below Data and Segments aren't
+// checked, as they depend on the data of the bar, neither are the
+// fields of a dot rebound by range or with to a value of unknown
+// type.
+//
+// The schemas of WithSchema, WithTaskBarTextSchema and the like are
+// validated the same way when the tasks are added, see MPBV2.AddBar.
+func CompileSchema(schema string, funcs ...template.FuncMap) (*Schema, error) {
+	var fm template.FuncMap
+	for _, f := range funcs {
+		fm = mergeFuncs(fm, f)
+	}
+	return compileSchema(schema, DetectProfile(), fm)
+}
+
+func compileSchema(schema string, p Profile, funcs template.FuncMap) (*Schema, error) {
+	tmpl, err := parseSchema(schema, p, funcs)
+	if err == nil {
+		c := fieldChecker{tmpl.Tree}
+		err = c.node(tmpl.Tree.Root, schemaDataType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &Schema{text: schema, tmpl: tmpl}, nil
+}
+
+// String returns the source of the schema, for WithSchema and the
+// like.
+func (s *Schema) String() string { return s.text }
+
+// Execute renders data with the schema into w, without translating
+// the color tags.
+func (s *Schema) Execute(w io.Writer, data *SchemaData) error {
+	return s.tmpl.Execute(w, data)
+}
+
+var schemaDataType = reflect.TypeFor[*SchemaData]()
+
+// fieldChecker walks the tree of a schema, and reports the first
+// field which can't be found in the type of the dot. A nil type is
+// unknown, and its fields aren't checked.
+type fieldChecker struct {
+	tree *parse.Tree
+}
+
+func (c fieldChecker) node(node parse.Node, dot reflect.Type) (err error) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			if err = c.node(child, dot); err != nil {
+				return
+			}
+		}
+	case *parse.ActionNode:
+		err = c.pipe(n.Pipe, dot)
+	case *parse.TemplateNode:
+		err = c.pipe(n.Pipe, dot)
+	case *parse.IfNode:
+		err = c.branch(&n.BranchNode, dot, dot)
+	case *parse.WithNode:
+		err = c.branch(&n.BranchNode, dot, c.typeOf(n.Pipe, dot))
+	case *parse.RangeNode:
+		err = c.branch(&n.BranchNode, dot, elemOf(c.typeOf(n.Pipe, dot)))
+	}
+	return
+}
+
+// branch checks the pipeline of n, its list with the dot inner and
+// its else list with dot.
+func (c fieldChecker) branch(n *parse.BranchNode, dot, inner reflect.Type) (err error) {
+	if err = c.pipe(n.Pipe, dot); err == nil {
+		if err = c.node(n.List, inner); err == nil {
+			err = c.node(n.ElseList, dot)
+		}
+	}
+	return
+}
+
+func (c fieldChecker) pipe(p *parse.PipeNode, dot reflect.Type) (err error) {
+	if p == nil {
+		return
+	}
+	for _, cmd := range p.Cmds {
+		for _, arg := range cmd.Args {
+			if err = c.arg(arg, dot); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (c fieldChecker) arg(arg parse.Node, dot reflect.Type) (err error) {
+	switch n := arg.(type) {
+	case *parse.FieldNode:
+		_, err = c.field(n, dot, n.Ident)
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" {
+			_, err = c.field(n, schemaDataType, n.Ident[1:])
+		}
+	case *parse.ChainNode:
+		err = c.arg(n.Node, dot)
+	case *parse.PipeNode:
+		err = c.pipe(n, dot)
+	}
+	return
+}
+
+// typeOf returns the type of a pipeline which is a field only, or
+// nil.
+func (c fieldChecker) typeOf(p *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if len(p.Decl) > 0 || len(p.Cmds) != 1 || len(p.Cmds[0].Args) != 1 {
+		return nil
+	}
+	var t reflect.Type
+	switch n := p.Cmds[0].Args[0].(type) {
+	case *parse.DotNode:
+		t = dot
+	case *parse.FieldNode:
+		t, _ = c.field(n, dot, n.Ident)
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" {
+			t, _ = c.field(n, schemaDataType, n.Ident[1:])
+		}
+	}
+	return t
+}
+
+// field returns the type of the field chain idents of t.
+func (c fieldChecker) field(node parse.Node, t reflect.Type, idents []string) (reflect.Type, error) {
+	for _, id := range idents {
+		if t == nil {
+			return nil, nil
+		}
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		m, isMethod := reflect.PointerTo(t).MethodByName(id)
+		f, isField := exportedField(t, id)
+		switch {
+		case isMethod && m.Type.NumOut() > 0:
+			t = m.Type.Out(0)
+		case isField:
+			t = f
+		case t.Kind() == reflect.Map:
+			t = t.Elem()
+		case isMethod, t.Kind() == reflect.Interface:
+			t = nil
+		default:
+			loc, ctx := c.tree.ErrorContext(node)
+			return nil, fmt.Errorf("template: %s: at <%s>: can't evaluate field %s in type %s", loc, ctx, id, t)
+		}
+		if t != nil && t.Kind() == reflect.Interface {
+			t = nil
+		}
+	}
+	return t, nil
+}
+
+// exportedField returns the type of the exported field id of the
+// struct t.
+func exportedField(t reflect.Type, id string) (reflect.Type, bool) {
+	if t.Kind() == reflect.Struct {
+		if f, ok := t.FieldByName(id); ok && f.IsExported() {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
+
+// elemOf returns the type of the elements ranged over, or nil.
+func elemOf(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if t = t.Elem(); t.Kind() != reflect.Interface {
+			return t
+		}
+	}
+	return nil
+}
+
+// schemaCompiler is implemented by the steppers and spinners.
+type schemaCompiler interface {
+	compile() error
+}
+
+// compileBar validates the schema of the stepper or spinner bt, see
+// CompileSchema.
+func compileBar(bt BarT) error {
+	if c, ok := bt.(schemaCompiler); ok {
+		return c.compile()
+	}
+	return nil
+}
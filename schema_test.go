@@ -0,0 +1,94 @@
+package progressbar
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/hedzr/progressbar/v2/pbtest"
+)
+
+func TestCompileSchema(t *testing.T) {
+	for _, schema := range []string{
+		`{{.Title}} {{.Bar}} {{.Data.Size}} {{.Segments.failed}} {{.Percent|right 7}}`,
+		`{{if gt .Data.Size 1000}}big{{end}} {{.Bar}}`,
+		`{{with .Data}}{{.Host}}{{end}} {{range $k, $v := .Segments}}{{$k}}={{$v}}{{end}}`,
+		`{{.ElapsedTime.Seconds}} {{.StartTime.Unix}} {{$.Title}} {{with .StartTime}}{{.Year}}{{end}}`,
+	} {
+		if _, err := CompileSchema(schema); err != nil {
+			t.Fatalf("expect %q valid, but got %v", schema, err)
+		}
+	}
+	for _, schema := range []string{
+		`{{.Titel}}`, `{{.Title|upper}}`, `{{.Title`, `{{.Title.Size}}`, `{{$.Titel}}`,
+		`{{.ElapsedTime.Nope}}`, `{{with .StartTime}}{{.Nope}}{{end}}`, `{{if .Done}}{{.Titel}}{{end}}`,
+	} {
+		if _, err := CompileSchema(schema); err == nil {
+			t.Fatalf("expect %q invalid", schema)
+		}
+	}
+
+	sc, err := CompileSchema(`{{.Title|upper}}`, template.FuncMap{"upper": strings.ToUpper})
+	if err != nil {
+		t.Fatalf("expect a valid schema with funcs, but got %v", err)
+	}
+	var sb strings.Builder
+	if err = sc.Execute(&sb, &SchemaData{Title: "x"}); err != nil || sb.String() != "X" || sc.String() != `{{.Title|upper}}` {
+		t.Fatalf("expect X rendered by %q, but got %q, %v", sc, sb.String(), err)
+	}
+}
+
+func TestInvalidSchema(t *testing.T) {
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 50, nil
+	}
+
+	if mpb, err := TryNewV2(WithSchema(`{{.Titel}}`)); mpb != nil || err == nil {
+		t.Fatal("expect the invalid schema of WithSchema returned by TryNewV2")
+	}
+	mpb := NewV2(WithOutput(pbtest.New(80, 10)), WithSchema(`{{.Titel}}`))
+	if mpb.Err() == nil {
+		t.Fatal("expect the invalid schema of WithSchema reported by Err")
+	}
+	if err := mpb.AddBar("Group", "Task #0", 0, 100, job); err == nil || mpb.GroupByName("Group") != nil {
+		t.Fatalf("expect AddBar failed without a group added, but got %v", err)
+	}
+
+	mpb = NewV2(WithOutput(pbtest.New(80, 10)))
+	if err := mpb.AddBar("Group", "Task #0", 0, 100, job, WithTaskBarStepper(0, WithStepperSchema(`{{.Title|nope}}`))); err == nil {
+		t.Fatal("expect the invalid schema of a task reported by AddBar")
+	}
+	if mpb.GroupByName("Group") != nil {
+		t.Fatal("expect no group added for the failed task")
+	}
+	if err := mpb.AddBar("Group", "Task #1", 0, 100, job); err != nil || mpb.Err() != nil {
+		t.Fatalf("expect a valid task added, but got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	mpb.Run(ctx)
+}
+
+func TestSchemaErrorLoggedOnce(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	var buf bytes.Buffer
+	w := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(w)
+
+	tsk := &TaskBar{Name: "x", max: 100, progress: 50, startTime: time.Now()}
+	WithTaskBarStepper(0, WithStepperProfile(ProfileASCII),
+		WithStepperSchema(`{{.Title}} {{index .Segments.none 1}}`))(tsk)
+	for range 3 {
+		_ = tsk.String()
+	}
+	if n := strings.Count(buf.String(), "Error:"); n != 1 {
+		t.Fatalf("expect the error logged once, but got %d: %q", n, buf.String())
+	}
+}
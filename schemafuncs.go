@@ -0,0 +1,79 @@
+package progressbar
+
+import (
+	"maps"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// builtinFuncs returns the functions available in every schema of
+// the steppers and spinners, see WithStepperSchemaFuncs. The
+// ellipsis of truncate depends on p.
+func builtinFuncs(p Profile) template.FuncMap {
+	return template.FuncMap{
+		"bytes":    func(v any) string { return UnitBytes.format(toFloat(v)) },
+		"duration": durfmt,
+		"left":     func(w int, s string) string { return alignText(s, w, AlignLeft) },
+		"right":    func(w int, s string) string { return alignText(s, w, AlignRight) },
+		"center":   func(w int, s string) string { return alignText(s, w, AlignCenter) },
+		"truncate": func(w int, s string) string { return ellipsizeWith(s, w, p.ellipsis()) },
+		"color": func(name, s string) string {
+			return `<font color="` + name + `">` + s + `</font>`
+		},
+		"bold": func(s string) string { return "<b>" + s + "</b>" },
+		"dim":  func(s string) string { return "<dim>" + s + "</dim>" },
+		"ternary": func(cond bool, a, b any) any {
+			if cond {
+				return a
+			}
+			return b
+		},
+	}
+}
+
+// toFloat converts a number in a schema, such as .Data.Size, to
+// float64, or returns 0.
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case time.Duration:
+		return float64(n)
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+// mergeFuncs returns the functions of funcs and more, without
+// touching funcs which may be shared by the clones of a stepper.
+func mergeFuncs(funcs, more template.FuncMap) template.FuncMap {
+	m := maps.Clone(funcs)
+	if m == nil {
+		m = make(template.FuncMap, len(more))
+	}
+	maps.Copy(m, more)
+	return m
+}
+
+// parseSchema parses schema with the built-in functions and funcs,
+// the latter take precedence.
+func parseSchema(schema string, p Profile, funcs template.FuncMap) (*template.Template, error) {
+	return template.New("bar-build").Funcs(builtinFuncs(p)).Funcs(funcs).Parse(schema)
+}
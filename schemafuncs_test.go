@@ -0,0 +1,47 @@
+package progressbar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestSchemaFuncs(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tsk := &TaskBar{Name: "0123456789", max: 100, progress: 50, startTime: time.Now()}
+	WithTaskBarStepper(0, WithStepperProfile(ProfileASCII),
+		WithStepperSchema(`[{{.Title|truncate 6}}|{{"ab"|right 4}}|{{"ab"|center 5}}|{{"ab"|left 4}}|{{bytes 2560}}|{{.Title|upper}}]`))(tsk)
+
+	// the schema is parsed lazily, so the functions may come after it.
+	WithTaskBarSchemaFuncs(template.FuncMap{"upper": strings.ToUpper})(tsk)
+	if got, expect := screenOf(tsk.String()), "[012...|  ab| ab  |ab  |2.5kB|0123456789]"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+	if steppers[0].funcs != nil {
+		t.Fatal("expect the registered stepper untouched")
+	}
+}
+
+func TestSchemaState(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tsk := &TaskBar{Name: "x", max: 100, progress: 50, startTime: time.Now()}
+	WithTaskBarStepper(0, WithStepperProfile(ProfileASCII),
+		WithStepperSchema(`{{.State}}{{ternary (eq .State "failed") " x" ""}}`))(tsk)
+	if got, expect := screenOf(tsk.String()), "running"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+
+	tsk.progress = 100
+	if got, expect := screenOf(tsk.String()), "succeeded"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+
+	tsk.fail(errors.New("boom"))
+	if got, expect := screenOf(tsk.String()), "failed x"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+}
@@ -0,0 +1,66 @@
+package progressbar
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/hedzr/is/term/color"
+)
+
+// Segment describes a part of a stacked bar, such as the succeeded
+// items of a batch job. See StepSegment and WithStepperSegments.
+type Segment struct {
+	Name  string      // the name used by StepSegment
+	Glyph string      // the glyph of the part, empty to use the glyph of the completed part
+	Color color.Color // the color of the part
+}
+
+// DefaultSegments are the segments of a stacked bar if the stepper
+// doesn't specify its own. The pending part is drawn with the glyph
+// of the remaining part in the base color (dark gray).
+var DefaultSegments = []Segment{
+	{Name: "succeeded", Color: color.FgGreen},
+	{Name: "failed", Color: color.FgRed},
+	{Name: "skipped", Color: color.FgYellow},
+}
+
+// SegmentStepper is implemented by the bars which count the progress
+// by segments, both TaskBar and the bars of New, such as the bar
+// passed to a Worker:
+//
+//	bar.(progressbar.SegmentStepper).StepSegment("failed", 1)
+type SegmentStepper interface {
+	StepSegment(name string, delta int64) // update the progress of a segment, see SchemaData.Segments
+	Segments() map[string]int64           // the counts of the segments
+}
+
+// segmentsOf returns the counts of the segments of a bar, or nil.
+func segmentsOf(bar MiniResizeableBar) map[string]int64 {
+	if s, ok := bar.(SegmentStepper); ok {
+		return s.Segments()
+	}
+	return nil
+}
+
+// segCounter counts the progress of a bar by segments.
+type segCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *segCounter) add(name string, delta int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[name] += delta
+}
+
+// snapshot returns a copy of the counts, or nil if no segment was
+// stepped.
+func (c *segCounter) snapshot() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.counts)
+}
@@ -0,0 +1,58 @@
+package progressbar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hedzr/is/term/color"
+)
+
+func TestStepSegment(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	newBar := func(p Profile) *TaskBar {
+		tsk := &TaskBar{Name: "x", max: 10, startTime: time.Now()}
+		WithTaskBarStepper(2,
+			WithStepperProfile(p), WithStepperWidth(10), WithStepperTailSpace(-1),
+			WithStepperSchema(`[{{.Bar}}] {{.Segments.succeeded}}/{{.Segments.failed}}`),
+			WithStepperSegments(
+				Segment{Name: "succeeded", Glyph: "=", Color: color.FgGreen},
+				Segment{Name: "failed", Glyph: "x", Color: color.FgRed},
+				Segment{Name: "skipped", Color: color.FgYellow},
+			),
+		)(tsk)
+		tsk.StepSegment("failed", 2)
+		tsk.StepSegment("succeeded", 5)
+		tsk.StepSegment("skipped", 1)
+		return tsk
+	}
+
+	tsk := newBar(ProfileASCII)
+	if got, expect := screenOf(tsk.String()), "[=====xx+--] 5/2"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+	if got := newBar(ProfileTrueColor).String(); !strings.Contains(got, "\x1b[32m=====") {
+		t.Fatalf("expect the succeeded segment in green, but got %q", got)
+	}
+
+	tsk.StepSegment("failed", 2)
+	if !tsk.Completed() {
+		t.Fatal("expect the segments count into the progress")
+	}
+	if got, expect := screenOf(tsk.String()), "[=====xxxx+] 5/4"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+	if got, expect := tsk.Segments(), map[string]int64{"succeeded": 5, "failed": 4, "skipped": 1}; len(got) != len(expect) || got["failed"] != 4 {
+		t.Fatalf("expect the counts %v, but got %v", expect, got)
+	}
+}
+
+func TestSegmentStepper(t *testing.T) {
+	var bars = []MiniResizeableBar{&TaskBar{max: 10}, &pbar{max: 10}}
+	for _, bar := range bars {
+		if _, ok := bar.(SegmentStepper); !ok {
+			t.Fatalf("expect %T a SegmentStepper", bar)
+		}
+	}
+}
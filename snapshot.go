@@ -0,0 +1,164 @@
+package progressbar
+
+import (
+	"strings"
+	"time"
+)
+
+// UnknownTotal is the upper bound of a bar whose total is unknown,
+// such as a download without Content-Length. Such a bar is drawn
+// as an indeterminate animation, and switches to the determinate
+// one once UpdateRange supplies a real upper bound.
+const UnknownTotal int64 = -1
+
+// indeterminate tells whether the total of a bar with the bounds
+// is unknown.
+func indeterminate(min, max int64) bool { return max < min }
+
+// reached tells whether pos reaches the upper bound. It is never
+// true if the total is unknown.
+func reached(min, max, pos int64) bool { return !indeterminate(min, max) && pos >= max }
+
+// barSnapshot is the state of a bar at a moment. It feeds both
+// SchemaData and the JSON events.
+type barSnapshot struct {
+	min, max, pos int64
+	percent       float64 // 0..1, 0 if indeterminate
+	elapsed       time.Duration
+	speed         float64       // the smoothed speed, units per second
+	instSpeed     float64       // the speed within the last sample interval
+	avgSpeed      float64       // the average speed, units per second
+	remaining     time.Duration // the estimated time to go, -1 if unknown
+	indeterminate bool          // the total is unknown
+	segments      map[string]int64
+	state         TaskState
+	err           error // the error of a failed or cancelled bar
+	startTime     time.Time
+	failed        bool
+	data          any // see WithTaskBarData
+}
+
+func snapshotOf(bar MiniResizeableBar) (s barSnapshot) {
+	s.min, s.max, s.pos = bar.State()
+	s.indeterminate = indeterminate(s.min, s.max)
+	if s.max > s.min {
+		s.percent = min(float64(s.pos)/float64(s.max-s.min), 1)
+	} else if bar.Completed() {
+		s.percent = 1
+	}
+	s.segments = segmentsOf(bar)
+	s.state, s.err = stateOf(bar), barErr(bar)
+	s.failed = s.state == TaskFailed
+	if b, ok := bar.(dataHolder); ok {
+		s.startTime, s.data = b.started(), b.userData()
+	}
+	s.elapsed = bar.Dur()
+	var ok bool
+	if e, yes := bar.(estimator); yes {
+		s.speed, s.instSpeed, s.avgSpeed, ok = e.estimator().estimates()
+	}
+	if secs := s.elapsed.Seconds(); !ok && secs > 0 {
+		s.speed = float64(s.pos) / secs
+		s.instSpeed, s.avgSpeed = s.speed, s.speed
+	}
+	s.remaining = eta(s.min, s.max, s.pos, s.speed)
+	return
+}
+
+// newSchemaData returns the data of bar from its snapshot st, with
+// the title, and the quantities formatted in the unit u. The columns
+// are padded if bar is in a table layout. The fields of the stepper
+// or spinner, such as Bar and Indent, are left to it.
+func newSchemaData(bar MiniResizeableBar, st barSnapshot, title string, u Unit) *SchemaData {
+	data := schemaDataOf(bar, st, title, u)
+	if t := tableOf(bar); t != nil {
+		t.pad(data)
+	}
+	return data
+}
+
+// schemaDataOf is newSchemaData without the table layout.
+func schemaDataOf(bar MiniResizeableBar, st barSnapshot, title string, u Unit) *SchemaData {
+	data := &SchemaData{
+		Percent:      fltfmtpercent(st.percent),
+		PercentFloat: st.percent,
+		Title:        title,
+		Current:      u.format(float64(st.pos)),
+		Total:        u.format(float64(st.max)),
+		Speed:        u.speed(st.speed),
+		Elapsed:      durfmt(st.elapsed),
+		ElapsedTime:  st.elapsed,
+		Segments:     st.segments,
+		State:        st.state.String(),
+		Error:        errText(st.err),
+		Data:         st.data,
+		Min:          st.min,
+		Max:          st.max,
+		Pos:          st.pos,
+		BytesPerSec:  st.speed,
+		StartTime:    st.startTime,
+		Done:         bar.Completed(),
+		Failed:       st.failed,
+	}
+	setEstimates(data, st, u)
+	markIndeterminate(data, st)
+	return data
+}
+
+// settle fixes the upper bound of a bar to its position if the
+// total is still unknown once the work is done, such as the EOF of
+// a download without Content-Length.
+func settle(bar MiniResizeableBar) {
+	if min, max, pos := bar.State(); indeterminate(min, max) {
+		bar.UpdateRange(min, pos)
+	}
+}
+
+// markIndeterminate adjusts data if the total of the bar is
+// unknown: the percent is blank and the total is "?".
+func markIndeterminate(data *SchemaData, st barSnapshot) {
+	if !st.indeterminate {
+		return
+	}
+	data.Indeterminate = true
+	data.Percent = strings.Repeat(" ", len(fltfmtpercent(0)))
+	data.Total = "?"
+}
+
+// failer is implemented by the bars which can be marked failed,
+// such as a downloading bar whose request failed.
+type failer interface {
+	Err() error
+	fail(err error)
+}
+
+// barErr returns the error of a failed bar, or nil.
+func barErr(bar MiniResizeableBar) error {
+	if f, ok := bar.(failer); ok {
+		return f.Err()
+	}
+	return nil
+}
+
+// dataHolder is implemented by the bars which carry the custom
+// data and the start time into SchemaData.
+type dataHolder interface {
+	started() time.Time // zero if not started yet
+	userData() any
+}
+
+// stateOf returns the state of a bar, see TaskBar.Status. A bar
+// which doesn't know its state is running until it's completed or
+// failed.
+func stateOf(bar MiniResizeableBar) TaskState {
+	if s, ok := bar.(statuser); ok {
+		return s.Status()
+	}
+	switch err := barErr(bar); {
+	case err != nil:
+		return stateOfErr(err)
+	case bar.Completed():
+		return TaskSucceeded
+	}
+	return TaskRunning
+}
@@ -0,0 +1,116 @@
+package progressbar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hedzr/progressbar/v2/pbtest"
+)
+
+func TestBouncePos(t *testing.T) {
+	for _, c := range []struct {
+		steps, span, expect int
+	}{
+		{0, 5, 0}, {3, 5, 3}, {5, 5, 5}, {6, 5, 4}, {10, 5, 0}, {12, 5, 2}, {7, 0, 0},
+	} {
+		if got := bouncePos(time.Duration(c.steps)*bounceInterval, c.span); got != c.expect {
+			t.Fatalf("bouncePos(%d, %d): expect %d, but got %d", c.steps, c.span, c.expect, got)
+		}
+	}
+}
+
+func TestIndeterminateTaskBar(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tsk := &TaskBar{Name: "x", max: UnknownTotal, progress: 2048, startTime: time.Now()}
+	tsk.stepper = steppers[2].clone(
+		WithStepperProfile(ProfileASCII), WithStepperWidth(10), WithStepperTailSpace(-1),
+		WithStepperSchema(`{{.Title}} [{{.Bar}}] {{.Percent}}|{{.Current}}/{{.Total}}{{if .Indeterminate}} ?{{end}}`),
+	)
+	if tsk.Completed() || tsk.PercentF() != 0 {
+		t.Fatal("expect a bar of unknown total never completed")
+	}
+	if got, expect := screenOf(tsk.String()), "x [+++-------]       |2kB/? ?"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+
+	tsk.UpdateRange(0, 4096)
+	if got, expect := screenOf(tsk.String()), "x [++++++----]  50.0%|2kB/4kB"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+}
+
+func TestIndeterminatePBar(t *testing.T) {
+	pb := &pbar{mpbar: &mpbar{sigRedraw: make(chan struct{}, 16)}, max: UnknownTotal, title: "x"}
+	pb.Step(30)
+	if pb.Completed() {
+		t.Fatal("expect a bar of unknown total never completed by writing")
+	}
+	settle(pb)
+	if lb, ub, pos := pb.Bounds(); !pb.Completed() || lb != 0 || ub != 30 || pos != 30 {
+		t.Fatalf("expect settled to 0..30, but got %d..%d at %d", lb, ub, pos)
+	}
+}
+
+func TestDownloadUnknownTotal(t *testing.T) {
+	fixTermSize(t, 80, 10)
+
+	// no Content-Length, the response is chunked.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 3 {
+			_, _ = w.Write([]byte(strings.Repeat("x", 1000)))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+
+	fn := filepath.Join(t.TempDir(), "chunked")
+	mpb := New(WithOutputDevice(pbtest.New(80, 10)), WithMultiBarRenderMode(RenderTTY))
+	tasks := NewDownloadTasks(mpb)
+	tasks.Add(srv.URL, fn)
+	pb := mpb.(*mpbar2).bars[0]
+
+	done := make(chan struct{})
+	go func() {
+		tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the download completed")
+	}
+	tasks.Close()
+
+	if lb, ub, pos := pb.Bounds(); !pb.Completed() || lb != 0 || ub != 3000 || pos != 3000 {
+		t.Fatalf("expect completed at 3000, but got %d..%d at %d", lb, ub, pos)
+	}
+	if fi, err := os.Stat(fn); err != nil || fi.Size() != 3000 {
+		t.Fatalf("expect 3000 bytes downloaded, but got %v, %v", fi, err)
+	}
+}
+
+func TestSchemaRawFields(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tsk := &TaskBar{Name: "x", min: 0, max: 100, progress: 50, startTime: time.Now()}
+	WithTaskBarStepper(0, WithStepperProfile(ProfileASCII),
+		WithStepperSchema(`{{.Title}} {{.Data.Host}} {{.Pos}}/{{.Max}}{{if gt .Pos 40}} big{{end}} {{.Done}} {{.Failed}} {{.StartTime.IsZero}}`))(tsk)
+	WithTaskBarData(struct{ Host string }{"example.com"})(tsk)
+	WithTaskBarOnDataPrepared(func(bar MiniResizeableBar, data *SchemaData) {
+		data.Title = strings.ToUpper(data.Title)
+	})(tsk)
+	if got, expect := screenOf(tsk.String()), "X example.com 50/100 big false false false"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+
+	tsk.progress = 100
+	if got, expect := screenOf(tsk.String()), "X example.com 100/100 big true false false"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+}
@@ -7,14 +7,17 @@ import (
 	"bytes"
 	"log"
 	"strings"
-	"sync/atomic"
 	"text/template"
 	"time"
 
 	"github.com/hedzr/is/term/color"
 )
 
-func MaxSpinners() int { return len(spinners) }
+func MaxSpinners() int {
+	muRegistry.RLock()
+	defer muRegistry.RUnlock()
+	return len(spinners)
+}
 
 // The following spinner templates are modified from https://github.com/schollz/progressbar
 var spinners = map[int]*spinner{
@@ -98,20 +101,33 @@ var spinners = map[int]*spinner{
 	// https://github.com/schollz/progressbar/blob/master/spinners.go
 }
 
+// asciiSpinner is the spinner whose frames are used if the
+// terminal cannot display the unicode ones.
+const asciiSpinner = 9
+
+// spinnerInterval is the default duration of each frame of a
+// spinner.
+const spinnerInterval = 100 * time.Millisecond
+
 type spinner struct {
 	tr               color.Translator
 	onDraw           func(pb *pbar)
 	tmpl             *template.Template
+	funcs            template.FuncMap // see WithStepperSchemaFuncs
+	errLogged        bool             // an error of the schema was logged
 	indentL          string
 	prepend          string
 	append           string
 	schema           string
 	chars            []string
 	barWidth         int
+	titleWidth       int
 	safetyTailSpaces int
-	gauge            int32
+	interval         time.Duration // the duration of each frame, 0 for spinnerInterval
 	clrBase          color.Color
 	clrHighlight     color.Color
+	profile          *Profile // nil to use DetectProfile()
+	units            *Unit    // nil to use UnitBytes
 }
 
 func (s *spinner) SetInitialValue(initial int64) {}
@@ -126,9 +142,67 @@ func (s *spinner) SetHighlightColor(clr color.Color) {
 	s.clrHighlight = clr
 }
 
+func (s *spinner) SetProfile(p Profile) {
+	s.profile = &p
+	s.tr = p.translator()
+	s.tmpl = nil // the ellipsis of truncate depends on it
+}
+
+// SetTheme applies the colors and schema of a theme, the glyphs
+// are ignored.
+func (s *spinner) SetTheme(t Theme) {
+	if t.BaseColor != nil {
+		s.clrBase = t.BaseColor
+	}
+	if t.HighlightColor != nil {
+		s.clrHighlight = t.HighlightColor
+	}
+	if t.Schema != "" {
+		s.SetSchema(t.Schema)
+	}
+}
+
+func (s *spinner) prof() Profile {
+	if s.profile != nil {
+		return *s.profile
+	}
+	return DetectProfile()
+}
+
+// frames returns the frames of the spinner, or the ones of the
+// ASCII spinner if the terminal cannot display them.
+func (s *spinner) frames() []string {
+	if !s.prof().Unicode && !isASCII(s.chars...) {
+		a, _ := spinnerAt(asciiSpinner)
+		return a.chars
+	}
+	return s.chars
+}
+
+// frame returns the index of the frame to draw after elapsed. It
+// depends on the wall-clock time only, so the spinner turns at the
+// same speed no matter how often the bar is repainted.
+func (s *spinner) frame(elapsed time.Duration) int {
+	interval := s.interval
+	if interval <= 0 {
+		interval = spinnerInterval
+	}
+	return int(elapsed/interval) % len(s.frames())
+}
+
+// SetFrameInterval specifies the duration of each frame.
+func (s *spinner) SetFrameInterval(d time.Duration) {
+	s.interval = d
+}
+
 func (s *spinner) SetSchema(schema string) {
 	s.schema = schema
-	s.updateSchema()
+	s.tmpl = nil // parsed by init, once the functions are known
+}
+
+func (s *spinner) SetSchemaFuncs(funcs template.FuncMap) {
+	s.funcs = mergeFuncs(s.funcs, funcs)
+	s.tmpl = nil
 }
 
 func (s *spinner) SetWidth(w int) {
@@ -151,9 +225,33 @@ func (s *spinner) SetExtraTailSpaces(howMany int) {
 	s.safetyTailSpaces = howMany
 }
 
+func (s *spinner) SetPartials(glyphs ...string) {}
+func (s *spinner) SetSegments(segs ...Segment)  {}
+
+func (s *spinner) SetUnit(u Unit) { s.units = &u }
+
+func (s *spinner) unit() Unit {
+	if s.units != nil {
+		return *s.units
+	}
+	return UnitBytes
+}
+
+func (s *spinner) SetTitleMaxWidth(w int) {
+	s.titleWidth = w
+}
+
+// clone returns a new instance of the spinner s for a bar, with
+// opts applied. The spinners in the registry are never modified.
+func (s *spinner) clone(opts ...StepperOpt) *spinner {
+	c := *s
+	c.errLogged = false
+	return c.init(opts...)
+}
+
 func (s *spinner) init(opts ...StepperOpt) *spinner {
 	if s.tr == nil {
-		s.tr = color.GetCPT()
+		s.tr = s.prof().translator()
 	}
 	if s.tmpl == nil {
 		s.updateSchema()
@@ -168,93 +266,49 @@ func (s *spinner) init(opts ...StepperOpt) *spinner {
 	return s
 }
 
-func (s *spinner) updateSchema() *spinner {
-	if s.schema == "" {
-		s.schema = defaultSchema
+// compile validates the schema, see CompileSchema. The template
+// is still parsed by init.
+func (s *spinner) compile() error {
+	schema := s.schema
+	if schema == "" {
+		schema = defaultSchema
 	}
-	if s.barWidth == 0 {
-		s.barWidth = barWidth
-	}
-	s.tmpl = template.Must(template.New("bar-build").Parse(s.schema))
-	return s
+	_, err := compileSchema(schema, s.prof(), s.funcs)
+	return err
 }
 
-func (s *spinner) buildBar(bar MiniResizeableBar, pos, barWidth int, half bool) string {
-	str := s.chars[pos]
-	if len(str) < s.barWidth {
-		str += strings.Repeat(" ", s.barWidth-len(str))
-	}
-	_ = bar
-	return str
+func (s *spinner) title(bar MiniResizeableBar) string {
+	return ellipsizeWith(bar.Title(), s.titleWidth, s.prof().ellipsis())
 }
 
-func (s *spinner) StringV1(pb *pbar) string {
-	return string(s.BytesV1(pb))
+// logErr logs the first error of the schema only, instead of one
+// for each frame.
+func (s *spinner) logErr(err error) {
+	if !s.errLogged {
+		s.errLogged = true
+		log.Printf("Error: %v", err)
+	}
 }
 
-func (s *spinner) BytesV1(pb *pbar) []byte {
-	// defer pb.locker()()
-
-	cnt := int(atomic.AddInt32(&s.gauge, 1)) % len(s.chars)
-
-	var percent float64
-	percent = float64(pb.read) / float64(pb.max-pb.min)
-	// if percent >= 100 {
-	// 	pb.completed = true
-	// }
-
-	if !pb.completed {
-		pb.stopTime = time.Now()
-	}
-	dur := pb.stopTime.Sub(pb.startTime)
-
-	read, suffix := humanizeBytes(float64(pb.read))
-	total, suffix1 := humanizeBytes(float64(pb.max))
-	speed, suffix2 := humanizeBytes(float64(pb.read) / dur.Seconds())
-
-	data := &SchemaData{
-		Indent:       s.indentL,
-		Prepend:      s.prepend,
-		Bar:          s.buildBar(pb, cnt, s.barWidth, false),
-		Percent:      fltfmtpercent(percent), // fmt.Sprintf("%.1f%%", percent),
-		PercentFloat: percent,                // percent = 61 => 61.0%
-		Title:        pb.title,               //
-		Current:      read + suffix,          // fmt.Sprintf("%v%v", read, suffix),
-		Total:        total + suffix1,        // fmt.Sprintf("%v%v", total, suffix1),
-		Speed:        speed + suffix2 + "/s", // fmt.Sprintf("%v%v/s", speed, suffix2),
-		Elapsed:      durfmt(dur),            // fmt.Sprintf("%v", dur), //nolint:gocritic
-		ElapsedTime:  dur,
-		Append:       s.append,
+func (s *spinner) updateSchema() *spinner {
+	if s.schema == "" {
+		s.schema = defaultSchema
 	}
-
-	s.init()
-
-	if pb.onDataPrepared != nil {
-		pb.onDataPrepared(pb, data)
+	if s.barWidth == 0 {
+		s.barWidth = barWidth
 	}
-
-	var sb bytes.Buffer
-	err := s.tmpl.Execute(&sb, data)
+	tmpl, err := parseSchema(s.schema, s.prof(), s.funcs)
 	if err != nil {
-		log.Printf("Error: %v", err)
-	}
-
-	if s.safetyTailSpaces > 0 {
-		sb.WriteString(strings.Repeat(" ", s.safetyTailSpaces))
+		s.logErr(err)
+		tmpl = template.Must(parseSchema(defaultSchema, s.prof(), nil))
 	}
+	s.tmpl = tmpl
+	return s
+}
 
-	str := s.tr.Translate(sb.String(), color.Reset)
-	return []byte(str)
-
-	// if pb.completed {
-	// 	return []byte(fmt.Sprintf("\r%s%s %.1fs (%v) %s Done.",
-	// 		indentChars, s.chars[cnt], percent, dur, pb.title))
-	// }
-	//
-	// str := []byte(fmt.Sprintf("\r%s%s %.1f%% %s (%v%v/%v%v, %v%v/s, %v)",
-	// 	indentChars, s.chars[cnt], percent, pb.title,
-	// 	read, suffix, total, suffix1, speed, suffix2, dur))
-	// return str
+func (s *spinner) buildBar(bar MiniResizeableBar, pos, barWidth int, half bool) string {
+	_ = bar
+	return padRight(s.frames()[pos], s.barWidth)
 }
 
 func (s *spinner) String(bar MiniResizeableBar) string {
@@ -264,15 +318,10 @@ func (s *spinner) String(bar MiniResizeableBar) string {
 func (s *spinner) Bytes(bar MiniResizeableBar) []byte {
 	// defer pb.locker()()
 
-	min, max, progress := bar.State()
-	percent := float64(progress) / float64(max-min)
-	if percent > 1 {
-		percent = 1
-	}
-
-	dur := bar.Dur()
+	st := frameSnapshot(bar)
+	dur := st.elapsed
 
-	cnt := int(atomic.AddInt32(&s.gauge, 1)) % len(s.chars)
+	cnt := s.frame(dur)
 
 	// var percent float64
 	// percent = float64(pb.read) / float64(pb.max-pb.min)
@@ -285,24 +334,9 @@ func (s *spinner) Bytes(bar MiniResizeableBar) []byte {
 	// }
 	// dur := pb.stopTime.Sub(pb.startTime)
 
-	read, suffix := humanizeBytes(float64(progress))
-	total, suffix1 := humanizeBytes(float64(max))
-	speed, suffix2 := humanizeBytes(float64(progress) / dur.Seconds())
-
-	data := &SchemaData{
-		Indent:       s.indentL,
-		Prepend:      s.prepend,
-		Bar:          s.buildBar(bar, cnt, s.barWidth, false),
-		Percent:      fltfmtpercent(percent), // fmt.Sprintf("%.1f%%", percent),
-		PercentFloat: percent,                // percent = 61 => 61.0%
-		Title:        bar.Title(),            //
-		Current:      read + suffix,          // fmt.Sprintf("%v%v", read, suffix),
-		Total:        total + suffix1,        // fmt.Sprintf("%v%v", total, suffix1),
-		Speed:        speed + suffix2 + "/s", // fmt.Sprintf("%v%v/s", speed, suffix2),
-		Elapsed:      durfmt(dur),            // fmt.Sprintf("%v", dur), //nolint:gocritic
-		ElapsedTime:  dur,
-		Append:       s.append,
-	}
+	data := newSchemaData(bar, st, s.title(bar), s.unit())
+	data.Indent, data.Prepend, data.Append = s.indentL, s.prepend, s.append
+	data.Bar = s.buildBar(bar, cnt, s.barWidth, false)
 
 	s.init()
 
@@ -311,7 +345,7 @@ func (s *spinner) Bytes(bar MiniResizeableBar) []byte {
 	var sb bytes.Buffer
 	err := s.tmpl.Execute(&sb, data)
 	if err != nil {
-		log.Printf("Error: %v", err)
+		s.logErr(err)
 	}
 
 	if s.safetyTailSpaces > 0 {
@@ -319,6 +353,9 @@ func (s *spinner) Bytes(bar MiniResizeableBar) []byte {
 	}
 
 	str := s.tr.Translate(sb.String(), color.Reset)
+	if cols, _ := termSize(); cols > 0 {
+		str = truncateWidth(str, cols-1)
+	}
 	return []byte(str)
 }
 
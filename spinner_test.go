@@ -0,0 +1,47 @@
+package progressbar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hedzr/progressbar/v2/pbtest"
+)
+
+func TestSpinnerFrame(t *testing.T) {
+	s := spinners[9].clone(WithStepperProfile(ProfileASCII))
+	for range 3 {
+		if got := s.frame(250 * time.Millisecond); got != 2 {
+			t.Fatalf("expect the frame selected by elapsed time, but got %d", got)
+		}
+	}
+
+	WithStepperFrameInterval(time.Second)(s)
+	if got := s.frame(2500 * time.Millisecond); got != 2 {
+		t.Fatalf("expect the frame selected by the interval, but got %d", got)
+	}
+	if spinners[9].interval != 0 {
+		t.Fatal("expect the registered spinner untouched")
+	}
+}
+
+func TestMultiBarHeartbeat(t *testing.T) {
+	fixTermSize(t, 80, 10)
+
+	term := pbtest.New(80, 10)
+	mpb := New(
+		WithOutputDevice(term), WithMultiBarRenderMode(RenderTTY),
+		WithMultiBarHeartbeat(5*time.Millisecond),
+	)
+	defer mpb.Close()
+	mpb.Add(100, "idle", WithBarSpinner(9), WithBarProfile(ProfileASCII),
+		WithBarTextSchema(`{{.Title}} {{.Bar}}`))
+
+	// no progress is made, the heartbeat keeps repainting.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(term.Frames()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect the idle spinner repainted, but got %q", term.Frames())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
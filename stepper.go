@@ -6,19 +6,23 @@ package progressbar
 import (
 	"bytes"
 	"log"
+	"math"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"text/template"
 	"time"
 
 	"github.com/hedzr/is/term/color"
 )
 
-func MaxSteppers() int { return len(steppers) }
+func MaxSteppers() int {
+	muRegistry.RLock()
+	defer muRegistry.RUnlock()
+	return len(steppers)
+}
 
 type BarT interface {
-	StringV1(pb *pbar) string
-	BytesV1(pb *pbar) []byte
-
 	String(bar MiniResizeableBar) string
 	Bytes(bar MiniResizeableBar) []byte
 
@@ -36,13 +40,30 @@ type BarT interface {
 	SetPrependText(s string)
 	SetAppendText(s string)
 	SetExtraTailSpaces(howMany int)
+	SetTitleMaxWidth(w int)
 
 	SetBaseColor(clr color.Color)
 	SetHighlightColor(clr color.Color)
+	SetProfile(p Profile)
+	SetTheme(t Theme)
+	SetFrameInterval(d time.Duration)
+	SetPartials(glyphs ...string)
+	SetSegments(segs ...Segment)
+	SetUnit(u Unit)
+	SetSchemaFuncs(funcs template.FuncMap)
 }
 
 type StepperOpt func(s BarT)
 
+// withDefaults returns defaults followed by opts, without touching
+// the backing array of defaults.
+func withDefaults(defaults, opts []StepperOpt) []StepperOpt {
+	if len(defaults) == 0 {
+		return opts
+	}
+	return append(slices.Clip(defaults), opts...)
+}
+
 func WithStepperResumeable(resumeable bool) StepperOpt {
 	return func(s BarT) {
 		s.SetResumeable(resumeable)
@@ -61,6 +82,36 @@ func WithStepperSchema(schema string) StepperOpt {
 	}
 }
 
+// WithStepperSchemaFuncs adds funcs to the functions of the schema,
+// they take precedence over the built-in ones:
+//
+//	bytes v            // 2.5kB, see UnitBytes
+//	duration d         // 1m5s, d rounded to seconds
+//	left w s           // s padded with spaces on the right to w columns
+//	right w s          // s padded with spaces on the left to w columns
+//	center w s         // s centered in w columns
+//	truncate w s       // s cut to w columns with an ellipsis
+//	color name s       // s in the color, such as "green" or "#ff8000"
+//	bold s, dim s      // s in bold or dim
+//	ternary cond a b   // a if cond is true, or b
+//
+// For example,
+//
+//	{{.Percent|right 7}} {{.Title|truncate 20|left 20}} {{ternary (eq .State "failed") (color "red" "x") ""}}
+//
+// The widths count the columns of the text, so pad the text before
+// coloring it.
+func WithStepperSchemaFuncs(funcs template.FuncMap) StepperOpt {
+	return func(s BarT) {
+		s.SetSchemaFuncs(funcs)
+	}
+}
+
+// WithStepperWidth sets the width of the bar. Use AutoWidth to
+// fill the remaining columns of the terminal.
+//
+// Whatever the width is, a line will never exceed the terminal
+// width, the bar will be shrunk if necessary.
 func WithStepperWidth(width int) StepperOpt {
 	return func(s BarT) {
 		s.SetWidth(width)
@@ -91,6 +142,15 @@ func WithStepperTailSpace(count int) StepperOpt {
 	}
 }
 
+// WithStepperTitleMaxWidth limits the title to w terminal columns,
+// a longer title will be cut with an ellipsis. Default is 0 (no
+// limit).
+func WithStepperTitleMaxWidth(w int) StepperOpt {
+	return func(s BarT) {
+		s.SetTitleMaxWidth(w)
+	}
+}
+
 func WithStepperBaseColor(clr color.Color) StepperOpt {
 	return func(s BarT) {
 		s.SetBaseColor(clr)
@@ -103,6 +163,58 @@ func WithStepperHighlightColor(clr color.Color) StepperOpt {
 	}
 }
 
+// WithStepperProfile specifies the color and glyph capabilities of
+// the terminal, instead of the detected one (see DetectProfile).
+func WithStepperProfile(p Profile) StepperOpt {
+	return func(s BarT) {
+		s.SetProfile(p)
+	}
+}
+
+// WithStepperTheme applies the glyphs, colors and schema of a
+// theme, such as ThemeCargo.
+func WithStepperTheme(t Theme) StepperOpt {
+	return func(s BarT) {
+		s.SetTheme(t)
+	}
+}
+
+// WithStepperFrameInterval specifies how long each frame of a
+// spinner is shown, default is 100ms. It is ignored by steppers.
+func WithStepperFrameInterval(d time.Duration) StepperOpt {
+	return func(s BarT) {
+		s.SetFrameInterval(d)
+	}
+}
+
+// WithStepperPartials specifies the glyphs of a cell from the least
+// to the fully filled, such as "▏", "▎", "▍", "▌", "▋", "▊", "▉",
+// "█". With N glyphs, the bar advances in 1/N cell steps instead
+// of half cells. It is ignored by spinners.
+func WithStepperPartials(glyphs ...string) StepperOpt {
+	return func(s BarT) {
+		s.SetPartials(glyphs...)
+	}
+}
+
+// WithStepperSegments specifies the segments of a stacked bar, in
+// the order drawn. Default is DefaultSegments. See StepSegment. It
+// is ignored by spinners.
+func WithStepperSegments(segs ...Segment) StepperOpt {
+	return func(s BarT) {
+		s.SetSegments(segs...)
+	}
+}
+
+// WithStepperUnit specifies how Current, Total and the speeds are
+// formatted, such as UnitCount or UnitOf("files"). Default is
+// UnitBytes.
+func WithStepperUnit(u Unit) StepperOpt {
+	return func(s BarT) {
+		s.SetUnit(u)
+	}
+}
+
 var steppers = map[int]*stepper{
 	// 0: python installer style
 	0: {unread: "━", read: "━", leftHalf: "╺", rightHalf: "╸", clrBase: color.FgDarkGray, clrHighlight: color.NewColor16m(173, 147, 77, false), clrHighlight16M: color.FgYellow},
@@ -112,25 +224,43 @@ var steppers = map[int]*stepper{
 	1: {unread: "▒", read: "▉", leftHalf: "▒", rightHalf: "▌", clrBase: color.FgDarkGray, clrHighlight: color.FgLightCyan},
 	2: {unread: "-", read: "+", leftHalf: "+", rightHalf: "+", clrBase: color.FgDarkGray, clrHighlight: color.FgYellow},
 	3: {unread: "&nbsp;", read: "=", leftHalf: ">", rightHalf: ">", clrBase: color.FgDarkGray, clrHighlight: color.FgYellow},
+
+	// 4: eighth blocks
+	4: {unread: " ", read: "█", partials: []string{"▏", "▎", "▍", "▌", "▋", "▊", "▉", "█"}, clrBase: color.FgDarkGray, clrHighlight: color.FgLightCyan},
 }
 
+// asciiStepper is the stepper whose glyphs are used if the
+// terminal cannot display the unicode ones.
+const asciiStepper = 2
+
 type stepper struct {
 	tr               color.Translator
 	tmpl             *template.Template
+	funcs            template.FuncMap // see WithStepperSchemaFuncs
+	errLogged        bool             // an error of the schema was logged
 	unread           string
 	read             string
 	leftHalf         string
 	rightHalf        string
+	leftCap          string
+	partials         []string  // the glyphs of a cell from the least to the fully filled, see WithStepperPartials
+	segments         []Segment // nil to use DefaultSegments
+	rightCap         string
 	indentL          string
 	prepend          string
 	append           string
 	schema           string
 	clrBase          color.Color
 	clrHighlight     color.Color
-	clrHighlight16M  color.Color
+	clrHighlight16M  color.Color // the highlight color on a 16-color terminal
+	clrDone          color.Color // the color of a completed bar, nil to use clrHighlight
+	clrFailed        color.Color // the color of a failed bar, nil to use clrHighlight
+	profile          *Profile    // nil to use DetectProfile()
+	units            *Unit       // nil to use UnitBytes
 	barWidth         int
+	titleWidth       int
 	safetyTailSpaces int
-	percent          float64
+	percent          uint64 // the bits of float64, accessed atomically
 	initial          int64
 	resumeable       bool
 }
@@ -147,6 +277,8 @@ func (s *stepper) Resumeable() bool {
 	return s.resumeable
 }
 
+func (s *stepper) SetFrameInterval(d time.Duration) {}
+
 func (s *stepper) SetBaseColor(clr color.Color) {
 	s.clrBase = clr
 }
@@ -155,9 +287,43 @@ func (s *stepper) SetHighlightColor(clr color.Color) {
 	s.clrHighlight = clr
 }
 
+func (s *stepper) SetProfile(p Profile) {
+	s.profile = &p
+	s.tr = p.translator()
+	s.tmpl = nil // the ellipsis of truncate depends on it
+}
+
+func (s *stepper) SetTheme(t Theme) {
+	if t.Read != "" {
+		s.read, s.unread, s.leftHalf, s.rightHalf = t.Read, t.Unread, t.LeftHalf, t.RightHalf
+	}
+	if t.BaseColor != nil {
+		s.clrBase = t.BaseColor
+	}
+	if t.HighlightColor != nil {
+		s.clrHighlight, s.clrHighlight16M = t.HighlightColor, t.HighlightColor16
+	}
+	s.clrDone, s.clrFailed = t.DoneColor, t.FailedColor
+	if t.Schema != "" {
+		s.SetSchema(t.Schema)
+	}
+}
+
+func (s *stepper) prof() Profile {
+	if s.profile != nil {
+		return *s.profile
+	}
+	return DetectProfile()
+}
+
 func (s *stepper) SetSchema(schema string) {
 	s.schema = schema
-	s.updateSchema()
+	s.tmpl = nil // parsed by init, once the functions are known
+}
+
+func (s *stepper) SetSchemaFuncs(funcs template.FuncMap) {
+	s.funcs = mergeFuncs(s.funcs, funcs)
+	s.tmpl = nil
 }
 
 func (s *stepper) SetWidth(w int) {
@@ -180,9 +346,39 @@ func (s *stepper) SetExtraTailSpaces(howMany int) {
 	s.safetyTailSpaces = howMany
 }
 
+func (s *stepper) SetPartials(glyphs ...string) {
+	s.partials = append([]string(nil), glyphs...)
+}
+
+func (s *stepper) SetSegments(segs ...Segment) {
+	s.segments = append([]Segment(nil), segs...)
+}
+
+func (s *stepper) SetUnit(u Unit) { s.units = &u }
+
+func (s *stepper) unit() Unit {
+	if s.units != nil {
+		return *s.units
+	}
+	return UnitBytes
+}
+
+func (s *stepper) SetTitleMaxWidth(w int) {
+	s.titleWidth = w
+}
+
+// clone returns a new instance of the style s for a bar, with
+// opts applied. The styles in steppers are never modified, so that
+// the options and the state of a bar don't leak into the others.
+func (s *stepper) clone(opts ...StepperOpt) *stepper {
+	c := *s
+	c.percent, c.errLogged = 0, false
+	return c.init(opts...)
+}
+
 func (s *stepper) init(opts ...StepperOpt) *stepper {
 	if s.tr == nil {
-		s.tr = color.GetCPT()
+		s.tr = s.prof().translator()
 	}
 	if s.tmpl == nil {
 		s.updateSchema()
@@ -196,6 +392,30 @@ func (s *stepper) init(opts ...StepperOpt) *stepper {
 	return s
 }
 
+// compile validates the schema, see CompileSchema. The template
+// is still parsed by init.
+func (s *stepper) compile() error {
+	schema := s.schema
+	if schema == "" {
+		schema = defaultSchema
+	}
+	_, err := compileSchema(schema, s.prof(), s.funcs)
+	return err
+}
+
+func (s *stepper) title(bar MiniResizeableBar) string {
+	return ellipsizeWith(bar.Title(), s.titleWidth, s.prof().ellipsis())
+}
+
+// logErr logs the first error of the schema only, instead of one
+// for each frame.
+func (s *stepper) logErr(err error) {
+	if !s.errLogged {
+		s.errLogged = true
+		log.Printf("Error: %v", err)
+	}
+}
+
 func (s *stepper) updateSchema() *stepper {
 	if s.schema == "" {
 		s.schema = defaultSchema
@@ -203,173 +423,294 @@ func (s *stepper) updateSchema() *stepper {
 	if s.barWidth == 0 {
 		s.barWidth = barWidth
 	}
-	s.tmpl = template.Must(template.New("bar-build").Parse(s.schema))
+	tmpl, err := parseSchema(s.schema, s.prof(), s.funcs)
+	if err != nil {
+		s.logErr(err)
+		tmpl = template.Must(parseSchema(defaultSchema, s.prof(), nil))
+	}
+	s.tmpl = tmpl
 	return s
 }
 
-func (s *stepper) buildBar(pb MiniResizeableBar, pos, barWidth int, half bool) string {
+// buildBar returns the bar filled to percent. The head cell is
+// drawn with leftHalf or rightHalf in half-cell steps, or with the
+// partials of the style in 1/N-cell steps.
+func (s *stepper) buildBar(pb MiniResizeableBar, percent float64, barWidth int) string {
+	p := s.prof()
+	read, unread, leftHalf, rightHalf := s.glyphs(p)
+	partials := s.partialGlyphs(p)
+	base, highlight := p.adapt(s.clrBase, nil), p.adapt(s.clrHighlight, s.clrHighlight16M)
+	switch {
+	case s.clrFailed != nil && barErr(pb) != nil:
+		highlight = p.adapt(s.clrFailed, nil)
+	case s.clrDone != nil && pb.Completed():
+		highlight = p.adapt(s.clrDone, nil)
+	}
+	colored := func(sb *bytes.Buffer, clr color.Color, text string) { s.colored(sb, p, clr, text) }
+
+	n := max(len(partials), 2) // the steps of a cell
+	steps := int(percent * float64(barWidth) * float64(n))
+	pos, frac := steps/n, steps%n
+
+	leftCap, rightCap := s.caps(p)
+
 	var sb bytes.Buffer
 	var rightPart string
+	sb.WriteString(leftCap)
 	if pos > 0 {
-		leftPart := strings.Repeat(s.read, pos)
-		s.tr.ColoredFast(&sb, s.clrHighlight, s.tr.Translate(leftPart, color.Reset))
+		leftPart := strings.Repeat(read, pos)
+		colored(&sb, highlight, s.tr.Translate(leftPart, color.Reset))
 	}
 	if !pb.Completed() {
-		if half {
-			s.tr.ColoredFast(&sb, s.clrBase, s.leftHalf)
-		} else {
-			s.tr.ColoredFast(&sb, s.clrHighlight, s.rightHalf)
+		switch {
+		case len(partials) > 0 && frac > 0:
+			colored(&sb, highlight, partials[frac-1])
+		case len(partials) > 0:
+			colored(&sb, highlight, unread)
+		case frac == 0:
+			colored(&sb, base, leftHalf)
+		default:
+			colored(&sb, highlight, rightHalf)
 		}
 	}
 	if barWidth > pos {
-		rightPart = strings.Repeat(s.unread, barWidth-pos-1)
-		s.tr.ColoredFast(&sb, s.clrHighlight, s.tr.Translate(rightPart, color.Reset))
+		rightPart = strings.Repeat(unread, barWidth-pos-1)
+		colored(&sb, highlight, s.tr.Translate(rightPart, color.Reset))
 	}
+	sb.WriteString(rightCap)
 	return sb.String()
 }
 
-// func (s *stepper) buildPrepend(pb *pbar, pos, barWidth int, half bool) string {
-// 	var sb bytes.Buffer
-// 	return sb.String()
-// }
-//
-// func (s *stepper) buildAppend(pb *pbar, pos, barWidth int, half bool) string {
-// 	var sb bytes.Buffer
-// 	return sb.String()
-// }
-
-func (s *stepper) String(bar MiniResizeableBar) string {
-	min, max, progress := bar.State()
-	s.percent = float64(progress) / float64(max-min)
-	if s.percent > 1 {
-		s.percent = 1
+// partialGlyphs returns the partials of the style, or nil if the
+// terminal cannot display them.
+func (s *stepper) partialGlyphs(p Profile) []string {
+	if !p.Unicode && !isASCII(s.partials...) {
+		return nil
 	}
+	return s.partials
+}
 
-	dur := bar.Dur()
-
-	read, suffix := humanizeBytes(float64(progress))
-	total, suffix1 := humanizeBytes(float64(max))
-	speed, suffix2 := humanizeBytes(float64(progress) / dur.Seconds())
+// colored writes text in clr, or as is if the terminal has no
+// colors.
+func (s *stepper) colored(sb *bytes.Buffer, p Profile, clr color.Color, text string) {
+	if p.Colors == ColorNone || clr == color.NoColor {
+		sb.WriteString(text)
+		return
+	}
+	s.tr.ColoredFast(sb, clr, text)
+}
 
-	pos1 := int(s.percent * float64(s.barWidth) * 2)
-	half := pos1%2 == 0
-	pos := pos1 / 2
+// bounceInterval is the duration for the block of an indeterminate
+// bar to move a cell.
+const bounceInterval = 50 * time.Millisecond
 
-	var sb bytes.Buffer
-	data := &SchemaData{
-		Indent:       s.indentL,
-		Prepend:      s.prepend,
-		Bar:          s.buildBar(bar, pos, s.barWidth, half),
-		Percent:      fltfmtpercent(s.percent), // fmt.Sprintf("%.1f%%", percent),
-		PercentFloat: s.percent,                // percent = 61 => 61.0%
-		Title:        bar.Title(),              //
-		Current:      read + suffix,            // fmt.Sprintf("%v%v", read, suffix),
-		Total:        total + suffix1,          // fmt.Sprintf("%v%v", total, suffix1),
-		Speed:        speed + suffix2 + "/s",   // fmt.Sprintf("%v%v/s", speed, suffix2),
-		Elapsed:      durfmt(dur),              // fmt.Sprintf("%v", dur), //nolint:gocritic
-		ElapsedTime:  dur,
-		Append:       s.append,
+// buildBounce returns the bar of an indeterminate progress: a block
+// bouncing back and forth with the elapsed time.
+func (s *stepper) buildBounce(pb MiniResizeableBar, elapsed time.Duration, barWidth int) string {
+	p := s.prof()
+	read, unread, _, _ := s.glyphs(p)
+	base, highlight := p.adapt(s.clrBase, nil), p.adapt(s.clrHighlight, s.clrHighlight16M)
+	if s.clrFailed != nil && barErr(pb) != nil {
+		highlight = p.adapt(s.clrFailed, nil)
 	}
 
-	s.init()
+	block := min(max(barWidth/5, 3), barWidth)
+	pos := bouncePos(elapsed, barWidth-block)
+	leftCap, rightCap := s.caps(p)
 
-	bar.SchemaDataPrepared(data)
+	var sb bytes.Buffer
+	sb.WriteString(leftCap)
+	s.colored(&sb, p, base, strings.Repeat(unread, pos))
+	s.colored(&sb, p, highlight, strings.Repeat(read, block))
+	s.colored(&sb, p, base, strings.Repeat(unread, barWidth-block-pos))
+	sb.WriteString(rightCap)
+	return sb.String()
+}
 
-	err := s.tmpl.Execute(&sb, data)
-	if err != nil {
-		log.Printf("Error: %v", err)
+// buildSegments returns a stacked bar: the segments in the order of
+// the stepper, the progress not counted into them, and then the
+// pending part.
+func (s *stepper) buildSegments(st barSnapshot, barWidth int) string {
+	p := s.prof()
+	read, unread, _, _ := s.glyphs(p)
+	segs := s.segments
+	if len(segs) == 0 {
+		segs = DefaultSegments
 	}
 
-	// str := sb.Bytes()
-	// return str
-
-	if s.safetyTailSpaces > 0 {
-		sb.WriteString(strings.Repeat(" ", s.safetyTailSpaces))
+	span := float64(st.max - st.min)
+	var sb bytes.Buffer
+	var sum int64
+	var drawn int // the cells drawn
+	part := func(n int64, glyph string, clr color.Color) {
+		sum += n
+		end := min(int(float64(sum)/span*float64(barWidth)), barWidth)
+		if end > drawn {
+			s.colored(&sb, p, clr, s.tr.Translate(strings.Repeat(glyph, end-drawn), color.Reset))
+			drawn = end
+		}
 	}
 
-	str := s.tr.Translate(sb.String(), color.Reset)
-	return str
+	leftCap, rightCap := s.caps(p)
+	sb.WriteString(leftCap)
+	for _, seg := range segs {
+		glyph := seg.Glyph
+		if glyph == "" || (!p.Unicode && !isASCII(glyph)) {
+			glyph = read
+		}
+		part(st.segments[seg.Name], glyph, p.adapt(seg.Color, nil))
+	}
+	part(max(st.pos-sum, 0), read, p.adapt(s.clrHighlight, s.clrHighlight16M))
+	s.colored(&sb, p, p.adapt(s.clrBase, nil), s.tr.Translate(strings.Repeat(unread, barWidth-drawn), color.Reset))
+	sb.WriteString(rightCap)
+	return sb.String()
 }
 
-func (s *stepper) Bytes(bar MiniResizeableBar) []byte {
-	return []byte(s.String(bar))
+// bouncePos returns the position, in 0..span, of a block bouncing
+// back and forth after elapsed.
+func bouncePos(elapsed time.Duration, span int) int {
+	if span <= 0 {
+		return 0
+	}
+	i := int(elapsed/bounceInterval) % (2 * span)
+	if i > span {
+		i = 2*span - i
+	}
+	return i
 }
 
-func (s *stepper) BytesV1(pb *pbar) []byte {
-	s.percent = float64(max(s.initial, pb.read)) / float64(pb.max-pb.min)
-	if s.percent > 1 {
-		s.percent = 1
+// caps returns the caps around the bar, "[" and "]" instead if the
+// terminal cannot display them.
+func (s *stepper) caps(p Profile) (leftCap, rightCap string) {
+	if !p.Unicode && !isASCII(s.leftCap, s.rightCap) {
+		return "[", "]"
 	}
+	return s.leftCap, s.rightCap
+}
 
-	if !pb.completed {
-		pb.stopTime = time.Now()
+// glyphs returns the glyphs of the bar, or the ones of the ASCII
+// stepper if the terminal cannot display them.
+func (s *stepper) glyphs(p Profile) (read, unread, leftHalf, rightHalf string) {
+	read, unread, leftHalf, rightHalf = s.read, s.unread, s.leftHalf, s.rightHalf
+	if p.Unicode {
+		return
 	}
-	dur := pb.stopTime.Sub(pb.startTime)
+	if !isASCII(read, unread, leftHalf, rightHalf) {
+		a, _ := stepperAt(asciiStepper)
+		return a.read, a.unread, a.leftHalf, a.rightHalf
+	}
+	nbsp := func(g string) string { return strings.ReplaceAll(g, "&nbsp;", " ") }
+	return nbsp(read), nbsp(unread), nbsp(leftHalf), nbsp(rightHalf)
+}
 
-	read, suffix := humanizeBytes(float64(pb.read))
-	total, suffix1 := humanizeBytes(float64(pb.max))
-	speed, suffix2 := humanizeBytes(float64(pb.read) / dur.Seconds())
+// func (s *stepper) buildPrepend(pb *pbar, pos, barWidth int, half bool) string {
+// 	var sb bytes.Buffer
+// 	return sb.String()
+// }
+//
+// func (s *stepper) buildAppend(pb *pbar, pos, barWidth int, half bool) string {
+// 	var sb bytes.Buffer
+// 	return sb.String()
+// }
 
-	pos1 := int(s.percent * float64(s.barWidth) * 2)
-	half := pos1%2 == 0
-	pos := pos1 / 2
+func (s *stepper) String(bar MiniResizeableBar) string {
+	st := frameSnapshot(bar)
+	percent, dur := st.percent, st.elapsed
+	s.setPercent(percent)
 
-	var sb bytes.Buffer
-	data := &SchemaData{
-		Indent:       s.indentL,
-		Prepend:      s.prepend,
-		Bar:          s.buildBar(pb, pos, s.barWidth, half),
-		Percent:      fltfmtpercent(s.percent), // fmt.Sprintf("%.1f%%", percent),
-		PercentFloat: s.percent,                // percent = 61 => 61.0%
-		Title:        pb.title,                 //
-		Current:      read + suffix,            // fmt.Sprintf("%v%v", read, suffix),
-		Total:        total + suffix1,          // fmt.Sprintf("%v%v", total, suffix1),
-		Speed:        speed + suffix2 + "/s",   // fmt.Sprintf("%v%v/s", speed, suffix2),
-		Elapsed:      durfmt(dur),              // fmt.Sprintf("%v", dur), //nolint:gocritic
-		ElapsedTime:  dur,
-		Append:       s.append,
-	}
+	data := newSchemaData(bar, st, s.title(bar), s.unit())
+	data.Indent, data.Prepend, data.Append = s.indentL, s.prepend, s.append
 
 	s.init()
 
-	if pb.onDataPrepared != nil {
-		pb.onDataPrepared(pb, data)
+	cols, _ := termSize()
+	w := s.fitWidth(data, cols)
+	switch {
+	case st.indeterminate:
+		data.Bar = s.buildBounce(bar, dur, w)
+	case len(st.segments) > 0 && st.max > st.min:
+		data.Bar = s.buildSegments(st, w)
+	default:
+		data.Bar = s.buildBar(bar, percent, w)
 	}
 
-	err := s.tmpl.Execute(&sb, data)
-	if err != nil {
-		log.Printf("Error: %v", err)
+	bar.SchemaDataPrepared(data)
+
+	return s.render(data, cols)
+}
+
+// fitWidth returns the bar width so that the whole line fits into
+// a terminal with cols columns. The bar fills the remaining columns
+// if its width is AutoWidth.
+func (s *stepper) fitWidth(data *SchemaData, cols int) int {
+	w := s.barWidth
+	if cols <= 0 {
+		if w < 0 {
+			return barWidth
+		}
+		return w
 	}
 
-	// str := sb.Bytes()
-	// return str
+	var sb bytes.Buffer
+	_ = s.init().tmpl.Execute(&sb, data) // data.Bar is empty here
+	avail := cols - 1 - displayWidth(s.tr.Translate(sb.String(), color.Reset)) - displayWidth(s.leftCap+s.rightCap)
+	if w < 0 || w > avail {
+		w = avail
+	}
+	if w < minBarWidth {
+		w = minBarWidth
+	}
+	return w
+}
+
+// render executes the schema template with data, and cuts the
+// result to fit into a terminal with cols columns.
+func (s *stepper) render(data *SchemaData, cols int) string {
+	var sb bytes.Buffer
+	err := s.init().tmpl.Execute(&sb, data)
+	if err != nil {
+		s.logErr(err)
+	}
 
 	if s.safetyTailSpaces > 0 {
 		sb.WriteString(strings.Repeat(" ", s.safetyTailSpaces))
 	}
 
 	str := s.tr.Translate(sb.String(), color.Reset)
-	return []byte(str)
+	if cols > 0 {
+		str = truncateWidth(str, cols-1)
+	}
+	return str
 }
 
-func (s *stepper) StringV1(pb *pbar) string {
-	return string(s.BytesV1(pb))
+func (s *stepper) Bytes(bar MiniResizeableBar) []byte {
+	return []byte(s.String(bar))
 }
 
 func (s *stepper) Percent() string {
-	return fltfmtpercent(s.percent)
+	return fltfmtpercent(s.PercentF())
 }
 
 func (s *stepper) PercentF() float64 {
-	return s.percent
+	return math.Float64frombits(atomic.LoadUint64(&s.percent))
 }
 
 func (s *stepper) PercentI() int {
-	return int(s.percent*100 + 0.5)
+	return int(s.PercentF()*100 + 0.5)
+}
+
+func (s *stepper) setPercent(percent float64) {
+	atomic.StoreUint64(&s.percent, math.Float64bits(percent))
 }
 
 const (
-	defaultSchema = `{{.Indent}}{{.Prepend}} {{.Bar}} {{.Percent}} | <font color="green">{{.Title}}</font> | {{.Current}}/{{.Total}} {{.Speed}} {{.Elapsed}} {{.Append}}`
+	defaultSchema = `{{.Indent}}{{.Prepend}} {{.Bar}} {{.Percent}} | <font color="green">{{.Title}}</font> | {{.Current}}/{{.Total}} {{.Speed}} {{.Elapsed}} {{.Append}}{{if .Error}} <font color="red">{{.State}}: {{.Error}}</font>{{end}}`
 	barWidth      = 30
+	minBarWidth   = 4
 	indentChars   = `    `
 )
+
+// AutoWidth can be passed to WithBarWidth, WithStepperWidth, etc.
+// to let the bar fill the remaining columns of the terminal after
+// the other fields of the schema.
+const AutoWidth = -1
@@ -30,6 +30,9 @@ func durfmt(d time.Duration) string {
 func humanizeBytes(s float64) (value, suffix string) {
 	sizes := []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
 	base := 1024.0
+	if math.IsNaN(s) || math.IsInf(s, 0) {
+		s = 0 // such as the speed within zero duration
+	}
 	if s < 10 {
 		// return fmt.Sprintf("%2.0f", s), "B"
 		return strconv.FormatFloat(s, 'f', 0, 64), "B"
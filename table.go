@@ -0,0 +1,176 @@
+package progressbar
+
+import (
+	"strings"
+	"sync"
+)
+
+// Column is a field of SchemaData aligned by the table layout, see
+// WithTableLayout.
+type Column int
+
+const (
+	ColumnTitle   Column = iota // .Title
+	ColumnPercent               // .Percent
+	ColumnCurrent               // .Current
+	ColumnTotal                 // .Total
+	ColumnSpeed                 // .Speed
+	ColumnElapsed               // .Elapsed
+	numColumns
+)
+
+// Align is how the text of a column is aligned.
+type Align int
+
+const (
+	AlignDefault Align = iota // left for ColumnTitle, right for the others
+	AlignLeft
+	AlignRight
+	AlignCenter
+)
+
+// ColumnAlign specifies the alignment of a column, see
+// WithTableLayout.
+type ColumnAlign struct {
+	Column Column
+	Align  Align
+}
+
+// tableLayout pads the columns of the bars drawn together, such as
+// the tasks of a group, to the same widths. The widths are measured
+// before each frame, and the bar fills the same width in each row
+// then.
+type tableLayout struct {
+	align  [numColumns]Align
+	mu     sync.Mutex
+	widths [numColumns]int
+	snaps  map[MiniResizeableBar]barSnapshot // measured, to be drawn
+}
+
+func newTableLayout(aligns []ColumnAlign) *tableLayout {
+	t := &tableLayout{}
+	for c := range t.align {
+		t.align[c] = AlignRight
+	}
+	t.align[ColumnTitle] = AlignLeft
+	for _, a := range aligns {
+		if a.Column >= 0 && a.Column < numColumns && a.Align != AlignDefault {
+			t.align[a.Column] = a.Align
+		}
+	}
+	return t
+}
+
+// tabled is implemented by the bars which may be drawn in a table
+// layout.
+type tabled interface {
+	layout() *tableLayout // nil if not in a table layout
+	Bar() BarT            // the stepper or spinner, nil if not picked yet
+}
+
+type tabledBar interface {
+	MiniResizeableBar
+	tabled
+}
+
+// tableOf returns the table layout of a bar, or nil.
+func tableOf(bar MiniResizeableBar) *tableLayout {
+	if b, ok := bar.(tabled); ok {
+		return b.layout()
+	}
+	return nil
+}
+
+// measure takes the widths of the columns of bars, the rows of the
+// next frame.
+func measure[B tabledBar](t *tableLayout, bars []B) {
+	if t == nil {
+		return
+	}
+	var widths [numColumns]int
+	snaps := make(map[MiniResizeableBar]barSnapshot, len(bars))
+	for _, bar := range bars {
+		bt := bar.Bar()
+		if bt == nil {
+			continue
+		}
+		st := snapshotOf(bar)
+		snaps[bar] = st
+		for c, cell := range columnsOf(cellsOf(bar, bt, st)) {
+			widths[c] = max(widths[c], displayWidth(*cell))
+		}
+	}
+	t.mu.Lock()
+	t.widths, t.snaps = widths, snaps
+	t.mu.Unlock()
+}
+
+// cellsOf returns the data of a bar as the stepper or spinner bt
+// would format it from the snapshot st.
+func cellsOf(bar MiniResizeableBar, bt BarT, st barSnapshot) *SchemaData {
+	title := bar.Title()
+	if t, ok := bt.(titler); ok {
+		title = t.title(bar)
+	}
+	return schemaDataOf(bar, st, title, unitOf(bt))
+}
+
+// columnsOf returns the fields of data aligned by the table layout.
+func columnsOf(data *SchemaData) [numColumns]*string {
+	return [numColumns]*string{
+		ColumnTitle:   &data.Title,
+		ColumnPercent: &data.Percent,
+		ColumnCurrent: &data.Current,
+		ColumnTotal:   &data.Total,
+		ColumnSpeed:   &data.Speed,
+		ColumnElapsed: &data.Elapsed,
+	}
+}
+
+// snapshot takes the snapshot of bar measured for the frame, so the
+// bar is drawn with the same values as the widths are measured.
+func (t *tableLayout) snapshot(bar MiniResizeableBar) (st barSnapshot, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if st, ok = t.snaps[bar]; ok {
+		delete(t.snaps, bar)
+	}
+	return
+}
+
+// pad aligns the columns of data to the widths measured.
+func (t *tableLayout) pad(data *SchemaData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for c, f := range columnsOf(data) {
+		*f = alignText(*f, t.widths[c], t.align[c])
+	}
+}
+
+// frameSnapshot returns the snapshot of bar measured by its table
+// layout for the frame, or takes a new one.
+func frameSnapshot(bar MiniResizeableBar) barSnapshot {
+	if t := tableOf(bar); t != nil {
+		if st, ok := t.snapshot(bar); ok {
+			return st
+		}
+	}
+	return snapshotOf(bar)
+}
+
+// alignText pads s with spaces to w columns.
+func alignText(s string, w int, a Align) string {
+	n := max(w-displayWidth(s), 0)
+	switch a {
+	case AlignRight:
+		return strings.Repeat(" ", n) + s
+	case AlignCenter:
+		return strings.Repeat(" ", n/2) + s + strings.Repeat(" ", n-n/2)
+	}
+	return s + strings.Repeat(" ", n)
+}
+
+// titler is implemented by the steppers and spinners.
+type titler interface {
+	title(bar MiniResizeableBar) string // the title ellipsized to the max width
+}
@@ -0,0 +1,99 @@
+package progressbar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hedzr/progressbar/v2/pbtest"
+)
+
+func TestTableLayout(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tbl := newTableLayout([]ColumnAlign{{Column: ColumnCurrent, Align: AlignCenter}})
+	var tasks []*TaskBar
+	for _, c := range []struct {
+		name     string
+		progress int64
+	}{{"a", 5}, {"longer", 2048}} {
+		tsk := &TaskBar{Name: c.name, max: 4096, progress: c.progress, startTime: time.Now(), table: tbl}
+		WithTaskBarStepper(0, WithStepperProfile(ProfileASCII), WithStepperWidth(AutoWidth),
+			WithStepperTailSpace(-1), WithStepperSchema(`{{.Title}}|{{.Current}}|{{.Total}}|{{.Bar}}|`))(tsk)
+		tasks = append(tasks, tsk)
+	}
+
+	measure(tbl, tasks)
+	a, b := screenOf(tasks[0].String()), screenOf(tasks[1].String())
+	if expect := "a     |5B |4kB|"; a[:len(expect)] != expect {
+		t.Fatalf("expect %q aligned, but got %q", expect, a)
+	}
+	if expect := "longer|2kB|4kB|"; b[:len(expect)] != expect {
+		t.Fatalf("expect %q aligned, but got %q", expect, b)
+	}
+	if len(a) != len(b) {
+		t.Fatalf("expect the bars of the same width, but got\n%q\n%q", a, b)
+	}
+}
+
+func TestTableLayoutSnapshot(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tbl := newTableLayout(nil)
+	var tasks []*TaskBar
+	for _, progress := range []int64{100, 50} {
+		tsk := &TaskBar{Name: "x", max: 100, progress: progress, startTime: time.Now(), table: tbl}
+		WithTaskBarStepper(0, WithStepperProfile(ProfileASCII), WithStepperSchema(`{{.Percent}}|{{.Current}}|`))(tsk)
+		tasks = append(tasks, tsk)
+	}
+
+	measure(tbl, tasks)
+	tasks[1].progress = 60 // moved after measured, drawn in the next frame
+	if got, expect := screenOf(tasks[0].String()), "100.0%|100B|"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+	if got, expect := screenOf(tasks[1].String()), " 50.0%| 50B|"; got != expect {
+		t.Fatalf("expect the measured percent padded, but got %q", got)
+	}
+	if got, expect := screenOf(tasks[1].String()), " 60.0%| 60B|"; got != expect {
+		t.Fatalf("expect the snapshot taken once, but got %q", got)
+	}
+}
+
+func TestAlignText(t *testing.T) {
+	for _, c := range []struct {
+		s      string
+		w      int
+		a      Align
+		expect string
+	}{
+		{"ab", 5, AlignLeft, "ab   "},
+		{"ab", 5, AlignRight, "   ab"},
+		{"ab", 5, AlignCenter, " ab  "},
+		{"下载", 6, AlignRight, "  下载"},
+		{"abcdef", 3, AlignRight, "abcdef"},
+	} {
+		if got := alignText(c.s, c.w, c.a); got != c.expect {
+			t.Fatalf("alignText(%q, %d, %d): expect %q, but got %q", c.s, c.w, c.a, c.expect, got)
+		}
+	}
+}
+
+func TestWithTableLayout(t *testing.T) {
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 50, nil
+	}
+	mpb := NewV2(WithOutput(pbtest.New(80, 10)), WithTableLayout())
+	_ = mpb.AddBar("Group", "a", 0, 100, job)
+	_ = mpb.AddBar("Group", "b", 0, 100, job)
+	grp := mpb.GroupByName("Group")
+	if grp.table == nil || grp.TaskByName("a").layout() != grp.table || grp.TaskByName("b").layout() != grp.table {
+		t.Fatal("expect the tasks of a group sharing its table layout")
+	}
+
+	mb := New(WithOutputDevice(pbtest.New(80, 10)), WithMultiBarTableLayout())
+	defer mb.Close()
+	mb.Add(100, "a")
+	if pb := mb.(*mpbar2).bars[0]; pb.layout() == nil || pb.layout() != mb.(*mpbar2).table {
+		t.Fatal("expect the bars sharing the table layout")
+	}
+}
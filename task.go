@@ -121,7 +121,7 @@ func (s *Tasks) Add(opts ...TaskOpt) *Tasks {
 	o = append(o, to.barOptions...)
 
 	s.bar.Add(
-		100,
+		UnknownTotal, // see WithBarUpperBound
 		to.title,
 		o...,
 	)
@@ -154,10 +154,13 @@ func (s *sTask) Close() {
 // 	Step(delta int64)
 // }
 
+// onStep runs the worker. A bar whose total is still unknown is
+// completed once the worker returns.
 func (s *sTask) onStep(bar MiniResizeableBar, exitCh <-chan struct{}) (stop bool) {
 	if s.onStepProc != nil {
 		s.onStepProc(bar, exitCh)
 	}
+	settle(bar)
 	return
 }
 
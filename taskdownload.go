@@ -159,8 +159,8 @@ func (s *DownloadTasks) Add(url string, filename any, opts ...Opt) {
 	o = append(o, opts...)
 
 	s.bar.Add(
-		100,
-		task.Title, // fmt.Sprintf("downloading %v", s.fn),
+		UnknownTotal, // updated once the response arrives
+		task.Title,   // fmt.Sprintf("downloading %v", s.fn),
 		// // WithBarSpinner(14),
 		// // WithBarStepper(3),
 		// WithBarStepper(0),
@@ -190,10 +190,39 @@ type DownloadTask struct {
 	wg        *sync.WaitGroup
 	doneCount int32
 	onStartCB OnStartCB
+	muErr     sync.Mutex // guards err
+	err       error
 
 	logger *slog.Logger
 }
 
+// Err returns the error if the downloading failed, or nil.
+func (s *DownloadTask) Err() error {
+	s.muErr.Lock()
+	defer s.muErr.Unlock()
+	return s.err
+}
+
+// fail logs the failure, and marks the task and its bar failed.
+// A failed task is terminated, so DownloadTasks.Wait won't wait
+// for it. Only the first failure is kept.
+func (s *DownloadTask) fail(bar MiniResizeableBar, msg string, err error, args ...any) {
+	s.muErr.Lock()
+	if s.err != nil {
+		s.muErr.Unlock()
+		return
+	}
+	s.err = fmt.Errorf("%s: %w", msg, err)
+	failure := s.err
+	s.muErr.Unlock()
+
+	s.logger.Error(msg, append([]any{"err", err}, args...)...)
+	if f, ok := bar.(failer); ok {
+		f.fail(failure)
+	}
+	s.terminateTrigger()
+}
+
 type OnStartCB func(task *DownloadTask, bar MiniResizeableBar) (err error)
 
 func (s *DownloadTask) Close() {
@@ -238,7 +267,9 @@ func (s *DownloadTask) terminateTrigger() {
 	if atomic.CompareAndSwapInt32(&s.doneCount, 0, 1) {
 		wg := s.wg
 		s.wg = nil
-		wg.Done()
+		if wg != nil {
+			wg.Done()
+		}
 	}
 }
 
@@ -278,7 +309,7 @@ func (s *DownloadTask) onStart(bar MiniResizeableBar) {
 
 		if s.onStartCB != nil {
 			if err = s.onStartCB(s, bar); err != nil {
-				s.logger.Error("user customized onStartCB returns failure state", "err", err)
+				s.fail(bar, "user customized onStartCB returns failure state", err)
 			}
 			return
 		}
@@ -291,14 +322,14 @@ func (s *DownloadTask) onStart(bar MiniResizeableBar) {
 
 		s.Req, err = http.NewRequest("GET", s.Url, nil) //nolint:gocritic
 		if err != nil {
-			s.logger.Error("creating a new http request failed", "err", err)
+			s.fail(bar, "creating a new http request failed", err)
 			return
 		}
 		if resumeable {
 			s.Req.Header.Set("Range", fmt.Sprintf("bytes=%v-", existingFileSize))
 			s.File, err = os.OpenFile(s.Filename, os.O_APPEND|os.O_WRONLY, 0o644)
 			if err != nil {
-				s.logger.Error("sending header for resumeable trunks failed", "err", err, "resume-point", existingFileSize)
+				s.fail(bar, "sending header for resumeable trunks failed", err, "resume-point", existingFileSize)
 				return
 			}
 			whence := io.SeekEnd
@@ -308,10 +339,14 @@ func (s *DownloadTask) onStart(bar MiniResizeableBar) {
 			s.File, err = os.OpenFile(s.Filename, os.O_CREATE|os.O_WRONLY, 0o644)
 		}
 		if err != nil {
-			s.logger.Error("opening/seeking on local file failed", "err", err)
+			s.fail(bar, "opening/seeking on local file failed", err)
 			return
 		}
 		s.Resp, err = http.DefaultClient.Do(s.Req)
+		if err != nil {
+			s.fail(bar, "getting http response object failed", err)
+			return
+		}
 		// println(s.Resp.StatusCode)
 		if s.Resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
 			const BUFFERSIZE = 4096
@@ -330,10 +365,6 @@ func (s *DownloadTask) onStart(bar MiniResizeableBar) {
 			s.logger.Debug(fmt.Sprintf("size of %q: %d/%d - resumeable enabled - seeked to end of file.\n", s.Filename, existingFileSize, s.Resp.ContentLength))
 			return
 		}
-		if err != nil {
-			s.logger.Error("getting http response object failed", "err", err)
-			return
-		}
 
 		const BUFFERSIZE = 4096
 		s.Buffer = make([]byte, BUFFERSIZE)
@@ -342,10 +373,14 @@ func (s *DownloadTask) onStart(bar MiniResizeableBar) {
 			if resumeable && existingFileSize > 0 {
 				bar.SetInitialValue(existingFileSize)
 			}
-			bar.UpdateRange(0, s.Resp.ContentLength+existingFileSize)
+			if s.Resp.ContentLength < 0 {
+				bar.UpdateRange(0, UnknownTotal)
+			} else {
+				bar.UpdateRange(0, s.Resp.ContentLength+existingFileSize)
+			}
 			s.logger.Debug(fmt.Sprintf("size of %q: %d/%d - resumeable enabled - seeked to end of file. PARTIAL\n", s.Filename, existingFileSize, s.Resp.ContentLength))
 		} else {
-			bar.UpdateRange(0, s.Resp.ContentLength)
+			bar.UpdateRange(0, s.Resp.ContentLength) // UnknownTotal if the length is unknown
 		}
 
 		s.Writer = io.MultiWriter(s.File, bar)
@@ -355,27 +390,27 @@ func (s *DownloadTask) onStart(bar MiniResizeableBar) {
 func (s *DownloadTask) doWorker(bar MiniResizeableBar, exitCh <-chan struct{}) (stop bool) {
 	// _, _ = io.Copy(s.w, s.resp.Body)
 
-	if s.Req == nil || s.File == nil {
-		return
+	if s.Err() != nil {
+		return // onStart failed already
 	}
-
-	if s.Resp == nil {
-		s.logger.Warn("invalid http request or response (nil).")
+	if s.Req == nil || s.File == nil || s.Resp == nil {
+		s.fail(bar, "invalid http request or response", errInvalidDownload)
 		return
 	}
 
 	for {
 		n, err := s.Resp.Body.Read(s.Buffer)
 		if err != nil && !errors.Is(err, io.EOF) {
-			s.logger.Error("reading from http response failed", "err", err)
+			s.fail(bar, "reading from http response failed", err)
 			return
 		}
 		if n == 0 {
+			settle(bar) // the total is known at EOF
 			break
 		}
 
 		if _, err = s.Writer.Write(s.Buffer[:n]); err != nil {
-			s.logger.Error("writing trunk to local file failed", "err", err)
+			s.fail(bar, "writing trunk to local file failed", err)
 			return
 		}
 
@@ -0,0 +1,59 @@
+package progressbar
+
+import (
+	"context"
+	"errors"
+)
+
+// TaskState is the state of a task, see TaskBar.Status.
+type TaskState int
+
+const (
+	TaskPending   TaskState = iota // not started yet
+	TaskRunning                    // started, neither finished nor failed
+	TaskSucceeded                  // completed
+	TaskFailed                     // its Job or download failed, see TaskBar.Err
+	TaskCancelled                  // the context of MPBV2.Run was done before it finished
+)
+
+func (s TaskState) String() string {
+	switch s {
+	case TaskPending:
+		return "pending"
+	case TaskRunning:
+		return "running"
+	case TaskSucceeded:
+		return "succeeded"
+	case TaskFailed:
+		return "failed"
+	default:
+		return "cancelled"
+	}
+}
+
+// Finished tells whether the task will never progress again.
+func (s TaskState) Finished() bool {
+	return s >= TaskSucceeded
+}
+
+// stateOfErr returns the state of a task which finished with err.
+func stateOfErr(err error) TaskState {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return TaskCancelled
+	}
+	return TaskFailed
+}
+
+// errText returns the message of err, or empty if err is nil.
+func errText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// statuser is implemented by the bars which know their state, such
+// as TaskBar.
+type statuser interface {
+	Status() TaskState
+}
@@ -0,0 +1,161 @@
+package progressbar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hedzr/progressbar/v2/pbtest"
+)
+
+func TestTaskState(t *testing.T) {
+	for s, expect := range []string{"pending", "running", "succeeded", "failed", "cancelled"} {
+		if got := TaskState(s).String(); got != expect {
+			t.Fatalf("expect %q, but got %q", expect, got)
+		}
+		if finished := TaskState(s).Finished(); finished != (s >= int(TaskSucceeded)) {
+			t.Fatalf("%v: unexpected Finished %v", TaskState(s), finished)
+		}
+	}
+
+	tsk := &TaskBar{Name: "x", max: 100}
+	for _, c := range []struct {
+		step   func()
+		expect TaskState
+	}{
+		{func() {}, TaskPending},
+		{tsk.markRunning, TaskRunning},
+		{func() { tsk.progress = 100 }, TaskSucceeded},
+		{func() { tsk.progress = 50; tsk.fail(context.Canceled) }, TaskCancelled},
+	} {
+		c.step()
+		if got := tsk.Status(); got != c.expect {
+			t.Fatalf("expect %v, but got %v", c.expect, got)
+		}
+	}
+}
+
+func TestJobFailure(t *testing.T) {
+	boom := errors.New("boom")
+	var calls int
+	failing := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		calls++
+		if progress == 30 {
+			return 0, boom
+		}
+		return 10, nil
+	}
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 10, nil
+	}
+
+	var buf bytes.Buffer
+	mpb := NewV2(WithOutput(&buf), WithRenderMode(RenderPlain), WithRefreshInterval(time.Millisecond))
+	_ = mpb.AddBar("Group", "bad", 0, 100, failing)
+	_ = mpb.AddBar("Group", "good", 0, 100, job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := mpb.Run(ctx)
+	if !errors.Is(err, boom) || !strings.Contains(err.Error(), "Group: bad: boom") {
+		t.Fatalf("expect the error of the failed job, but got %v", err)
+	}
+	if calls != 4 {
+		t.Fatalf("expect the failed job not retried, but it's called %d times", calls)
+	}
+
+	grp := mpb.GroupByName("Group")
+	if s := grp.TaskByName("bad").Status(); s != TaskFailed {
+		t.Fatalf("expect the task failed, but got %v", s)
+	}
+	if s := grp.TaskByName("good").Status(); s != TaskSucceeded {
+		t.Fatalf("expect the other task succeeded, but got %v", s)
+	}
+	if out := buf.String(); !strings.Contains(out, "bad: failed (30B/100B): boom\n") {
+		t.Fatalf("expect the failure line, but got %q", out)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		time.Sleep(time.Millisecond)
+		return 0, nil
+	}
+
+	mpb := NewV2(WithOutput(pbtest.New(80, 10)))
+	_ = mpb.AddBar("Group", "slow", 0, 100, job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := mpb.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect the task cancelled, but got %v", err)
+	}
+	if s := mpb.GroupByName("Group").TaskByName("slow").Status(); s != TaskCancelled {
+		t.Fatalf("expect the task cancelled, but got %v", s)
+	}
+}
+
+func TestTaskBarTimesRace(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tsk := &TaskBar{Name: "x", max: 100}
+	WithTaskBarStepper(0, WithStepperProfile(ProfileASCII))(tsk)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tsk.markRunning()
+		tsk.Increase(50)
+		tsk.fail(errors.New("boom"))
+	}()
+	for range 100 {
+		_, _ = tsk.Dur(), tsk.Status()
+		_ = tsk.String()
+	}
+	<-done
+
+	dur := tsk.Dur()
+	time.Sleep(time.Millisecond)
+	if got := tsk.Dur(); got != dur || tsk.Status() != TaskFailed {
+		t.Fatalf("expect the elapsed time stopped at the failure, but got %v, then %v", dur, got)
+	}
+}
+
+func TestBarFailedDur(t *testing.T) {
+	mb := New(WithOutputDevice(pbtest.New(80, 10)))
+	defer mb.Close()
+	mb.Add(100, "a")
+	pb := mb.(*mpbar2).bars[0]
+	_, _ = pb.Write(make([]byte, 30))
+
+	pb.fail(errors.New("boom"))
+	dur := pb.Dur()
+	time.Sleep(time.Millisecond)
+	if got := pb.Dur(); got != dur {
+		t.Fatalf("expect the elapsed time stopped at the failure, but got %v, then %v", dur, got)
+	}
+}
+
+func TestDownloadTaskFailOnce(t *testing.T) {
+	var log bytes.Buffer
+	s := &DownloadTask{Url: "://bad-url", logger: slog.New(slog.NewTextHandler(&log, nil))}
+	mb := New(WithOutputDevice(pbtest.New(80, 10)))
+	defer mb.Close()
+	mb.Add(100, "a")
+	bar := mb.(*mpbar2).bars[0]
+
+	s.onStart(bar)
+	if stop := s.doWorker(bar, nil); stop {
+		t.Fatal("expect the failed task not stopped by the worker")
+	}
+	if err := s.Err(); err == nil || !strings.HasPrefix(err.Error(), "creating a new http request failed: ") {
+		t.Fatalf("expect the error of onStart kept, but got %v", err)
+	}
+	if n := strings.Count(log.String(), "level=ERROR"); n != 1 {
+		t.Fatalf("expect the failure logged once, but got %q", log.String())
+	}
+}
@@ -0,0 +1,99 @@
+package progressbar
+
+import (
+	"io"
+	"strconv"
+	"sync"
+)
+
+// terminalProgress reports the overall progress of the bars to the
+// terminal: the tab/taskbar progress indicator via OSC 9;4, and
+// the percent in the window title via OSC 0.
+//
+// The sequences are emitted only when the overall progress changed,
+// and the original window title is pushed/popped with the xterm
+// window operations (CSI 22;0t and CSI 23;0t).
+type terminalProgress struct {
+	title   string // the window title, shown as "title 45%"
+	state   int
+	percent int
+	active  bool
+	mu      sync.Mutex
+}
+
+// the states of OSC 9;4
+const (
+	tpRemove        = 0
+	tpNormal        = 1
+	tpError         = 2
+	tpIndeterminate = 3
+)
+
+func newTerminalProgress(title string) *terminalProgress {
+	return &terminalProgress{title: title, state: -1, percent: -1}
+}
+
+// reportProgress aggregates the progress of the bars, weighted by
+// their sizes, and reports it via tp.
+func reportProgress[T MiniResizeableBar](w io.Writer, tp *terminalProgress, groups ...[]T) {
+	var total, done int64
+	var failed, unknown bool
+	for _, bars := range groups {
+		for _, bar := range bars {
+			lo, hi, pos := bar.State()
+			failed = failed || barErr(bar) != nil
+			if hi <= lo {
+				unknown = unknown || !bar.Completed()
+				continue
+			}
+			total += hi - lo
+			done += max(min(pos, hi), lo) - lo
+		}
+	}
+
+	state, percent := tpNormal, 0
+	if total > 0 {
+		percent = int(done * 100 / total)
+	}
+	switch {
+	case failed:
+		state = tpError
+	case unknown || total == 0:
+		state = tpIndeterminate
+	}
+	tp.update(w, state, percent)
+}
+
+func (tp *terminalProgress) update(w io.Writer, state, percent int) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if tp.active && state == tp.state && percent == tp.percent {
+		return
+	}
+	if !tp.active && tp.title != "" {
+		_, _ = io.WriteString(w, "\x1b[22;0t") // save the window title
+	}
+	tp.active, tp.state, tp.percent = true, state, percent
+
+	pct := strconv.Itoa(percent)
+	_, _ = io.WriteString(w, "\x1b]9;4;"+strconv.Itoa(state)+";"+pct+"\a")
+	if tp.title != "" {
+		_, _ = io.WriteString(w, "\x1b]0;"+tp.title+" "+pct+"%\a")
+	}
+}
+
+// clear removes the progress indicator and restores the window
+// title, nothing to do if nothing was reported.
+func (tp *terminalProgress) clear(w io.Writer) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if !tp.active {
+		return
+	}
+	tp.active, tp.state, tp.percent = false, -1, -1
+
+	_, _ = io.WriteString(w, "\x1b]9;4;"+strconv.Itoa(tpRemove)+";0\a")
+	if tp.title != "" {
+		_, _ = io.WriteString(w, "\x1b[23;0t") // restore the window title
+	}
+}
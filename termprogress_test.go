@@ -0,0 +1,73 @@
+package progressbar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hedzr/progressbar/v2/pbtest"
+)
+
+func TestReportProgress(t *testing.T) {
+	term := pbtest.New(40, 5)
+	fmt.Fprint(term, "\x1b]0;shell\a")
+
+	tp := newTerminalProgress("dl")
+	bars := []*pbar{
+		{mpbar: &mpbar{}, max: 100, read: 50},
+		{mpbar: &mpbar{}, max: 300, read: 0},
+	}
+	reportProgress(term, tp, bars)
+	if got := term.Progress(); got != (pbtest.Progress{State: tpNormal, Value: 12}) {
+		t.Fatalf("unexpected progress %+v", got)
+	}
+	if got := term.Title(); got != "dl 12%" {
+		t.Fatalf("unexpected title %q", got)
+	}
+
+	bars[1].err = errors.New("failed")
+	reportProgress(term, tp, bars)
+	if got := term.Progress(); got.State != tpError {
+		t.Fatalf("expect error state, but got %+v", got)
+	}
+
+	tp.clear(term)
+	if got := term.Progress(); got.State != tpRemove {
+		t.Fatalf("expect progress removed, but got %+v", got)
+	}
+	if got := term.Title(); got != "shell" {
+		t.Fatalf("expect title restored, but got %q", got)
+	}
+}
+
+func TestNewV2TerminalProgress(t *testing.T) {
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 25, nil
+	}
+
+	var buf bytes.Buffer
+	term := pbtest.New(80, 10)
+	mpb := NewV2(
+		WithOutput(io.MultiWriter(term, &buf)), WithRenderMode(RenderTTY), WithRefreshInterval(time.Millisecond),
+		WithTerminalProgress("dl"),
+	)
+	_ = mpb.AddBar("Group", "Task #0", 0, 100, job)
+	_ = mpb.AddBar("Group", "Task #1", 0, 100, job)
+	mpb.Run(context.Background())
+
+	if !strings.Contains(buf.String(), "\x1b]9;4;1;") {
+		t.Fatalf("expect the taskbar progress reported, but got %q", buf.String())
+	}
+	if got := term.Progress(); got.State != tpRemove {
+		t.Fatalf("expect progress removed after Run, but got %+v", got)
+	}
+	if got := term.Title(); got != "" {
+		t.Fatalf("expect title restored after Run, but got %q", got)
+	}
+	mpb.Close()
+}
@@ -0,0 +1,92 @@
+package progressbar
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hedzr/is"
+)
+
+// termSize returns the cached size of the terminal the bars are
+// drawn onto, see measureTerm, stdout by default. cols and rows are
+// zero if it's not a terminal.
+//
+// The size will be re-queried automatically when the terminal
+// window is resized (SIGWINCH on unix-like systems).
+func termSize() (cols, rows int) {
+	onceTermWatch.Do(startTermWatch)
+	return int(atomic.LoadInt32(&termCols)), int(atomic.LoadInt32(&termRows))
+}
+
+// addResizeListener registers fn to be invoked after the terminal
+// window was resized. The returned func unregisters it.
+func addResizeListener(fn func()) (remove func()) {
+	onceTermWatch.Do(startTermWatch)
+
+	resizeMu.Lock()
+	defer resizeMu.Unlock()
+	resizeSeq++
+	id := resizeSeq
+	resizeListeners[id] = fn
+	return func() {
+		resizeMu.Lock()
+		defer resizeMu.Unlock()
+		delete(resizeListeners, id)
+	}
+}
+
+// measureTerm takes out as the terminal to be measured by termSize,
+// if it's one. The last one wins if the bars are drawn onto several
+// terminals.
+func measureTerm(out io.Writer) {
+	if f, ok := out.(*os.File); ok && is.Tty(f) {
+		termFile.Store(f)
+		onceTermWatch.Do(startTermWatch)
+		queryTermSize()
+	}
+}
+
+func queryTermSize() {
+	f := termFile.Load()
+	if f == nil {
+		f = os.Stdout
+	}
+	cols, rows, err := is.GetTtySizeByFile(f)
+	if err != nil || cols <= 0 {
+		cols, rows = 0, 0
+	}
+	atomic.StoreInt32(&termCols, int32(cols))
+	atomic.StoreInt32(&termRows, int32(rows))
+}
+
+func onTermResized() {
+	queryTermSize()
+
+	resizeMu.Lock()
+	var fns []func()
+	for _, fn := range resizeListeners {
+		fns = append(fns, fn)
+	}
+	resizeMu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+func startTermWatch() {
+	queryTermSize()
+	watchTermResize(onTermResized)
+}
+
+var (
+	onceTermWatch   sync.Once
+	termFile        atomic.Pointer[os.File] // nil for stdout
+	termCols        int32
+	termRows        int32
+	resizeMu        sync.Mutex
+	resizeSeq       int
+	resizeListeners = make(map[int]func())
+)
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package progressbar
+
+// watchTermResize is a no-op on the platforms without SIGWINCH,
+// the terminal size is queried once at startup.
+func watchTermResize(cb func()) { _ = cb }
@@ -0,0 +1,19 @@
+//go:build unix
+
+package progressbar
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func watchTermResize(cb func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	go func() {
+		for range ch {
+			cb()
+		}
+	}()
+}
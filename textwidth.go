@@ -0,0 +1,110 @@
+package progressbar
+
+import (
+	"strings"
+
+	"github.com/hedzr/progressbar/v2/internal/cellwidth"
+)
+
+// displayWidth returns how many terminal columns s occupies,
+// the ANSI escape sequences in s are ignored. The East Asian
+// wide characters and emoji occupy two columns, the combining
+// marks occupy none.
+func displayWidth(s string) (n int) {
+	for i := 0; i < len(s); {
+		if l := escapeLen(s[i:]); l > 0 {
+			i += l
+			continue
+		}
+		size, w := cellwidth.Next(s[i:])
+		n += w
+		i += size
+	}
+	return
+}
+
+// truncateWidth cuts s so that it occupies w columns at most.
+// The ANSI escape sequences in s are kept as is.
+func truncateWidth(s string, w int) string {
+	if w <= 0 {
+		return ""
+	}
+	if displayWidth(s) <= w {
+		return s
+	}
+
+	var sb strings.Builder
+	var n int
+	for i := 0; i < len(s); {
+		if l := escapeLen(s[i:]); l > 0 {
+			_, _ = sb.WriteString(s[i : i+l])
+			i += l
+			continue
+		}
+		size, cw := cellwidth.Next(s[i:])
+		if n+cw > w {
+			n = w // stop here, even if a narrower char follows
+			i += size
+			continue // skip the printable runes, keep escapes
+		}
+		_, _ = sb.WriteString(s[i : i+size])
+		n += cw
+		i += size
+	}
+	return sb.String()
+}
+
+const ellipsis = "…"
+
+// ellipsize cuts s with an ellipsis so that it occupies w columns
+// at most. w <= 0 means unlimited.
+func ellipsize(s string, w int) string {
+	return ellipsizeWith(s, w, ellipsis)
+}
+
+// ellipsizeWith is like ellipsize but cuts s with tail.
+func ellipsizeWith(s string, w int, tail string) string {
+	if w <= 0 || displayWidth(s) <= w {
+		return s
+	}
+	if n := w - displayWidth(tail); n >= 0 {
+		return truncateWidth(s, n) + tail
+	}
+	return truncateWidth(s, w)
+}
+
+// padRight appends spaces to s until it occupies w columns.
+func padRight(s string, w int) string {
+	if n := displayWidth(s); n < w {
+		return s + strings.Repeat(" ", w-n)
+	}
+	return s
+}
+
+// escapeLen returns the length of the ANSI escape sequence at
+// the beginning of s, or 0 if s doesn't start with one.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' {
+		return 0
+	}
+	switch s[1] {
+	case '[': // CSI: ESC [ params final
+		for i := 2; i < len(s); i++ {
+			if c := s[i]; c >= 0x40 && c <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']': // OSC: ESC ] ... BEL or ESC \
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	default:
+		return 2
+	}
+	return len(s)
+}
@@ -0,0 +1,98 @@
+package progressbar
+
+import (
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	for i, cs := range []struct {
+		given  string
+		expect int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"\x1b[32mabc\x1b[0m", 3},
+		{"━━╸", 3},
+		{"\x1b]0;title\a12", 2},
+		{"中文", 4},
+		{"ｦｧ", 2},      // halfwidth katakana
+		{"e\u0301", 1}, // combining acute accent
+		{"\U0001F30D", 2},
+		{"\u2764\ufe0f", 2},               // emoji presentation
+		{"\U0001F469\u200D\U0001F4BB", 2}, // ZWJ sequence
+		{"\U0001F44D\U0001F3FD", 2},       // skin tone modifier
+		{"\U0001F1E8\U0001F1F3\U0001F1EF\U0001F1F5", 4}, // two flags
+	} {
+		if got := displayWidth(cs.given); got != cs.expect {
+			t.Fatalf("%5d. displayWidth(%q) expect %d but got %d", i, cs.given, cs.expect, got)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	for i, cs := range []struct {
+		given  string
+		width  int
+		expect string
+	}{
+		{"abcdef", 10, "abcdef"},
+		{"abcdef", 3, "abc"},
+		{"\x1b[32mabcdef\x1b[0m", 2, "\x1b[32mab\x1b[0m"},
+		{"abc", 0, ""},
+		{"中文字", 3, "中"},
+		{"a中文", 4, "a中"},
+	} {
+		if got := truncateWidth(cs.given, cs.width); got != cs.expect {
+			t.Fatalf("%5d. truncateWidth(%q, %d) expect %q but got %q", i, cs.given, cs.width, cs.expect, got)
+		}
+	}
+}
+
+func TestEllipsize(t *testing.T) {
+	for i, cs := range []struct {
+		given  string
+		width  int
+		expect string
+	}{
+		{"abcdef", 0, "abcdef"},
+		{"abcdef", 6, "abcdef"},
+		{"abcdef", 4, "abc…"},
+		{"下载文件", 5, "下载…"},
+		{"下载文件", 6, "下载…"},
+	} {
+		got := ellipsize(cs.given, cs.width)
+		if got != cs.expect {
+			t.Fatalf("%5d. ellipsize(%q, %d) expect %q but got %q", i, cs.given, cs.width, cs.expect, got)
+		}
+		if cs.width > 0 && displayWidth(got) > cs.width {
+			t.Fatalf("%5d. ellipsize(%q, %d) exceeds the width: %q", i, cs.given, cs.width, got)
+		}
+	}
+}
+
+func TestSpinnerPadding(t *testing.T) {
+	s := (&spinner{chars: []string{"\U0001F30D", "中", "ab"}}).init()
+	s.SetWidth(4)
+	for i := range s.chars {
+		if got := displayWidth(s.buildBar(nil, i, 4, false)); got != 4 {
+			t.Fatalf("expect spinner char %q padded to 4 columns, but got %d", s.chars[i], got)
+		}
+	}
+}
+
+func TestStepperFitWidth(t *testing.T) {
+	s := (&stepper{unread: "-", read: "+", leftHalf: "+", rightHalf: "+"}).init()
+	s.SetSchema("{{.Title}} [{{.Bar}}]")
+	data := &SchemaData{Title: "0123456789"}
+
+	if w := s.fitWidth(data, 0); w != barWidth {
+		t.Fatalf("expect default width %d if terminal is unknown, but got %d", barWidth, w)
+	}
+	if w := s.fitWidth(data, 40); w != 40-1-13 {
+		t.Fatalf("expect bar shrunk to %d, but got %d", 40-1-13, w)
+	}
+	s.SetWidth(AutoWidth)
+	if w := s.fitWidth(data, 100); w != 100-1-13 {
+		t.Fatalf("expect bar filled to %d, but got %d", 100-1-13, w)
+	}
+}
@@ -0,0 +1,134 @@
+package progressbar
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/hedzr/is/term/color"
+)
+
+// Theme bundles the look of the bars: the glyphs of a stepper, the
+// colors, the schema and the format of the group headers.
+//
+// The empty fields (and the nil colors) keep the settings of the
+// stepper. The glyphs are ignored by the spinners. Use color.NoColor
+// to draw a part without color.
+type Theme struct {
+	Name string
+
+	Read      string // the glyph of the completed part
+	Unread    string // the glyph of the remaining part
+	LeftHalf  string // the glyph at the head, on a cell boundary
+	RightHalf string // the glyph at the head, in the middle of a cell
+
+	BaseColor        color.Color // the color of the head on a cell boundary
+	HighlightColor   color.Color // the color of the bar
+	HighlightColor16 color.Color // the color of the bar on a 16-color terminal, optional
+	DoneColor        color.Color // the color of a completed bar, optional
+	FailedColor      color.Color // the color of a failed bar, optional
+
+	Schema string // see SchemaData
+
+	// GroupHeader is the template of the group titles, such as
+	// `<font color="cyan">{{.Name}}</font> ({{.Tasks}} tasks)`.
+	// See GroupHeaderData.
+	GroupHeader string
+}
+
+// GroupHeaderData is the data of the template Theme.GroupHeader.
+type GroupHeaderData struct {
+	Name  string // the group name
+	Tasks int    // how many tasks/bars in the group
+}
+
+// The built-in themes.
+var (
+	// ThemePip is the default look, like the python installer.
+	ThemePip = Theme{
+		Name: "pip",
+		Read: "━", Unread: "━", LeftHalf: "╺", RightHalf: "╸",
+		BaseColor:        color.FgDarkGray,
+		HighlightColor:   color.NewColor16m(173, 147, 77, false),
+		HighlightColor16: color.FgYellow,
+		DoneColor:        color.NewColor16m(114, 156, 31, false),
+		FailedColor:      color.FgRed,
+		Schema:           defaultSchema,
+	}
+
+	// ThemeCargo looks like the progress of rust cargo.
+	ThemeCargo = Theme{
+		Name: "cargo",
+		Read: "=", Unread: " ", LeftHalf: ">", RightHalf: ">",
+		BaseColor:      color.FgDarkGray,
+		HighlightColor: color.FgCyan,
+		DoneColor:      color.FgGreen,
+		FailedColor:    color.FgRed,
+		Schema:         `{{.Indent}}<font color="green">{{.Title}}</font> [{{.Bar}}] {{.Current}}/{{.Total}} {{.Elapsed}}`,
+		GroupHeader:    `<font color="cyan">{{.Name}}</font>`,
+	}
+
+	// ThemeDocker looks like the progress of docker pull.
+	ThemeDocker = Theme{
+		Name: "docker",
+		Read: "=", Unread: " ", LeftHalf: ">", RightHalf: ">",
+		BaseColor:      color.NoColor,
+		HighlightColor: color.NoColor,
+		FailedColor:    color.FgRed,
+		Schema:         `{{.Title}}: [{{.Bar}}] {{.Current}}/{{.Total}}`,
+		GroupHeader:    `{{.Name}}: {{.Tasks}} layers`,
+	}
+
+	// ThemeASCII draws the bars with ASCII chars and no color,
+	// for the dumb terminals and the log files.
+	ThemeASCII = Theme{
+		Name: "ascii",
+		Read: "#", Unread: "-", LeftHalf: "-", RightHalf: "#",
+		BaseColor:      color.NoColor,
+		HighlightColor: color.NoColor,
+		DoneColor:      color.NoColor,
+		FailedColor:    color.NoColor,
+		Schema:         `{{.Indent}}{{.Prepend}} [{{.Bar}}] {{.Percent}} | {{.Title}} | {{.Current}}/{{.Total}} {{.Speed}} {{.Elapsed}} {{.Append}}`,
+	}
+)
+
+// ThemeByName returns the built-in theme by its name.
+func ThemeByName(name string) (t Theme, ok bool) {
+	for _, t = range []Theme{ThemePip, ThemeCargo, ThemeDocker, ThemeASCII} {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Theme{}, false
+}
+
+// header returns the template of the group headers, or nil if the
+// theme has no GroupHeader. The template is validated against a
+// sample GroupHeaderData, as CompileSchema does.
+func (t *Theme) header() (*template.Template, error) {
+	if t == nil || t.GroupHeader == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("group-header").Parse(t.GroupHeader)
+	if err == nil {
+		err = tmpl.Execute(io.Discard, &GroupHeaderData{Name: "sample", Tasks: 1})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid group header: %w", err)
+	}
+	return tmpl, nil
+}
+
+// groupTitle formats the title of a group with tmpl, or returns
+// the name as is if tmpl is nil.
+func groupTitle(tmpl *template.Template, p Profile, name string, tasks int) string {
+	if tmpl == nil {
+		return name
+	}
+	var sb bytes.Buffer
+	if err := tmpl.Execute(&sb, &GroupHeaderData{Name: name, Tasks: tasks}); err != nil {
+		return name
+	}
+	return p.translator().Translate(sb.String(), color.Reset)
+}
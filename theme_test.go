@@ -0,0 +1,99 @@
+package progressbar
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hedzr/progressbar/v2/pbtest"
+)
+
+// screenOf returns what s looks like on a terminal.
+func screenOf(s string) string {
+	term := pbtest.New(80, 1)
+	_, _ = term.Write([]byte(s))
+	return term.Screen()
+}
+
+func TestThemeByName(t *testing.T) {
+	for _, name := range []string{"pip", "cargo", "docker", "ascii"} {
+		if th, ok := ThemeByName(name); !ok || th.Name != name {
+			t.Fatalf("expect the built-in theme %q, but got %+v", name, th)
+		}
+	}
+	if _, ok := ThemeByName("unknown"); ok {
+		t.Fatal("expect no theme named unknown")
+	}
+}
+
+func TestStepperTheme(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	newBar := func(progress int64) *TaskBar {
+		tsk := &TaskBar{Name: "serde", max: 100, progress: progress, startTime: time.Now()}
+		tsk.stepper = steppers[0].clone(
+			WithStepperProfile(ProfileTrueColor), WithStepperTheme(ThemeCargo),
+			WithStepperSchema(`{{.Title}} [{{.Bar}}]`), WithStepperWidth(10), WithStepperTailSpace(-1),
+		)
+		return tsk
+	}
+
+	tsk := newBar(50)
+	if got, expect := screenOf(tsk.String()), "serde [=====>    ]"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+	if got := tsk.String(); !strings.Contains(got, "\x1b[36m") {
+		t.Fatalf("expect the highlight color of theme, but got %q", got)
+	}
+
+	tsk = newBar(100)
+	if got := tsk.String(); !strings.Contains(got, "\x1b[32m==========") {
+		t.Fatalf("expect the done color of theme, but got %q", got)
+	}
+
+	tsk = newBar(30)
+	tsk.fail(errors.New("failed"))
+	if got := tsk.String(); !strings.Contains(got, "\x1b[31m===") {
+		t.Fatalf("expect the failed color of theme, but got %q", got)
+	}
+}
+
+func TestNewV2Theme(t *testing.T) {
+	fixTermSize(t, 60, 10)
+
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 25, nil
+	}
+
+	term := pbtest.New(60, 10)
+	mpb := NewV2(
+		WithOutput(term), WithRenderMode(RenderTTY), WithRefreshInterval(time.Millisecond),
+		WithProfile(ProfileTrueColor), WithTheme(ThemeDocker),
+		WithTaskOpts(WithTaskBarStepper(0, WithStepperWidth(20))),
+	)
+	_ = mpb.AddBar("alpine:3.20", "a1b2c3", 0, 100, job)
+	_ = mpb.AddBar("alpine:3.20", "d4e5f6", 0, 100, job)
+	mpb.Run(context.Background())
+	mpb.Close()
+
+	pbtest.Golden(t, "testdata/mpbv2-docker.golden", term.Screen())
+}
+
+func TestInvalidGroupHeader(t *testing.T) {
+	for _, header := range []string{`{{.Name`, `{{.Title}}`} {
+		th := ThemeCargo
+		th.GroupHeader = header
+		mpb := NewV2(WithOutput(pbtest.New(60, 10)), WithTheme(th))
+		if mpb.Err() == nil {
+			t.Fatalf("expect the invalid group header %q reported by Err", header)
+		}
+		job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+			return 25, nil
+		}
+		if err := mpb.AddBar("Group", "Task #0", 0, 100, job); err == nil {
+			t.Fatalf("expect AddBar failed with the invalid group header %q", header)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package progressbar
+
+import (
+	"math"
+	"strconv"
+)
+
+// UnitKind is how the quantities of a bar are scaled and labeled.
+type UnitKind int
+
+const (
+	// UnitKindBytes scales by 1024 with the labels kB, MB, ..., the
+	// legacy format of the bars.
+	UnitKindBytes UnitKind = iota
+	// UnitKindBytesIEC scales by 1024 with the labels KiB, MiB, ...
+	UnitKindBytesIEC
+	// UnitKindBytesSI scales by 1000 with the labels kB, MB, ...
+	UnitKindBytesSI
+	// UnitKindBits counts the bytes in bits, scaled by 1000 with the
+	// labels b, kb, Mb, ..., such as "80Mb/s".
+	UnitKindBits
+	// UnitKindCount shows plain numbers scaled by 1000 with the
+	// labels k, M, ..., followed by Unit.Name if any.
+	UnitKindCount
+)
+
+// Unit controls how Current, Total and the speeds in SchemaData are
+// formatted. See WithStepperUnit.
+type Unit struct {
+	Kind UnitKind
+	// Name is the name of the counted things for UnitKindCount,
+	// such as "files", which follows the numbers after a space.
+	Name string
+	// Precision is the digits after the point. Negative means the
+	// fewest digits needed to show one rounded digit, "1.5" or "2".
+	Precision int
+}
+
+var (
+	// UnitBytes is the default unit of the bars, "2.5MB" with base
+	// 1024.
+	UnitBytes = Unit{Kind: UnitKindBytes, Precision: -1}
+	// UnitBytesIEC is "2.5MiB" with base 1024.
+	UnitBytesIEC = Unit{Kind: UnitKindBytesIEC, Precision: -1}
+	// UnitBytesSI is "2.5MB" with base 1000.
+	UnitBytesSI = Unit{Kind: UnitKindBytesSI, Precision: -1}
+	// UnitBits is "20Mb" with base 1000, the bars count bytes.
+	UnitBits = Unit{Kind: UnitKindBits, Precision: -1}
+	// UnitCount is the plain numbers, "120" or "2.5k".
+	UnitCount = Unit{Kind: UnitKindCount, Precision: -1}
+)
+
+// UnitOf returns a count unit named name, such as UnitOf("files")
+// which shows "120 files" and "3 files/s".
+func UnitOf(name string) Unit {
+	return Unit{Kind: UnitKindCount, Name: name, Precision: -1}
+}
+
+var (
+	labelsBytes    = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	labelsBytesIEC = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	labelsBits     = []string{"b", "kb", "Mb", "Gb", "Tb", "Pb", "Eb"}
+	labelsCount    = []string{"", "k", "M", "G", "T", "P", "E"}
+)
+
+// format returns v formatted in the unit, such as "2.5MB".
+func (u Unit) format(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		v = 0 // such as the speed within zero duration
+	}
+	switch u.Kind {
+	case UnitKindBytesIEC:
+		return scale(v, 1024, labelsBytesIEC, u.Precision)
+	case UnitKindBytesSI:
+		return scale(v, 1000, labelsBytes, u.Precision)
+	case UnitKindBits:
+		return scale(v*8, 1000, labelsBits, u.Precision)
+	case UnitKindCount:
+		str := scale(v, 1000, labelsCount, u.Precision)
+		if u.Name != "" {
+			str += " " + u.Name
+		}
+		return str
+	}
+	if u.Precision < 0 {
+		value, suffix := humanizeBytes(v)
+		return value + suffix
+	}
+	return scale(v, 1024, labelsBytes, u.Precision)
+}
+
+// speed returns v formatted as a speed in the unit, such as
+// "2.5MB/s".
+func (u Unit) speed(v float64) string {
+	return u.format(v) + "/s"
+}
+
+func scale(v, base float64, labels []string, precision int) string {
+	e := 0
+	for e < len(labels)-1 && math.Abs(v) >= base {
+		v /= base
+		e++
+	}
+	if precision < 0 {
+		v = math.Round(v*10) / 10
+	}
+	return strconv.FormatFloat(v, 'f', precision, 64) + labels[e]
+}
+
+// unitHolder is implemented by the steppers, the spinners and the
+// bars which hold them.
+type unitHolder interface {
+	unit() Unit
+}
+
+// unitOf returns the unit of a bar or a stepper, or UnitBytes.
+func unitOf(v any) Unit {
+	if h, ok := v.(unitHolder); ok {
+		return h.unit()
+	}
+	return UnitBytes
+}
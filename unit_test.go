@@ -0,0 +1,53 @@
+package progressbar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnitFormat(t *testing.T) {
+	for _, c := range []struct {
+		u      Unit
+		v      float64
+		expect string
+	}{
+		{UnitBytes, 5, "5B"},
+		{UnitBytes, 2560, "2.5kB"},
+		{Unit{}, 2700, "3kB"},
+		{UnitBytesIEC, 2560, "2.5KiB"},
+		{UnitBytesIEC, 3 << 20, "3MiB"},
+		{UnitBytesSI, 2500, "2.5kB"},
+		{UnitBits, 1250000, "10Mb"},
+		{UnitCount, 120, "120"},
+		{UnitCount, 12345, "12.3k"},
+		{UnitOf("files"), 120, "120 files"},
+		{Unit{Kind: UnitKindCount, Name: "rows", Precision: 2}, 1500, "1.50k rows"},
+		{Unit{Kind: UnitKindBytesSI, Precision: 0}, 999, "999B"},
+	} {
+		if got := c.u.format(c.v); got != c.expect {
+			t.Fatalf("%+v.format(%v): expect %q, but got %q", c.u, c.v, c.expect, got)
+		}
+	}
+	if got := UnitOf("files").speed(3); got != "3 files/s" {
+		t.Fatalf("expect %q, but got %q", "3 files/s", got)
+	}
+}
+
+func TestTaskBarUnit(t *testing.T) {
+	fixTermSize(t, 80, 24)
+
+	tsk := &TaskBar{Name: "x", max: 500, progress: 120, startTime: time.Now()}
+	WithTaskBarStepper(0, WithStepperProfile(ProfileASCII),
+		WithStepperSchema(`{{.Current}}/{{.Total}}`))(tsk)
+	if got, expect := screenOf(tsk.String()), "120B/500B"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+
+	WithTaskBarUnit(UnitOf("files"))(tsk)
+	if got, expect := screenOf(tsk.String()), "120 files/500 files"; got != expect {
+		t.Fatalf("expect %q, but got %q", expect, got)
+	}
+	if u := unitOf(tsk); u.Name != "files" {
+		t.Fatalf("expect the unit of the bar from its stepper, but got %+v", u)
+	}
+}
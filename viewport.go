@@ -0,0 +1,106 @@
+package progressbar
+
+import (
+	"strconv"
+	"strings"
+)
+
+// viewport picks the bars to be shown within maxRows lines.
+//
+// If the bars cannot be shown entirely, the active (running)
+// bars are kept visible first, then the queued ones, then the
+// done ones, and the hidden bars are summarized into one line:
+//
+//	… and 14 more (9 done, 5 queued)
+//
+// The visible bars keep their original order. maxRows <= 0
+// means unlimited.
+func viewport[T MiniResizeableBar](bars []T, maxRows int) (visible []T, summary string) {
+	if maxRows <= 0 || len(bars) <= maxRows {
+		return bars, ""
+	}
+
+	slots := maxRows - 1 // reserve a line for the summary
+	shown := make([]bool, len(bars))
+	for _, want := range []barPhase{phaseRunning, phaseQueued, phaseDone} {
+		for i, bar := range bars {
+			if slots > 0 && !shown[i] && phaseOf(bar) == want {
+				shown[i] = true
+				slots--
+			}
+		}
+	}
+
+	var hidden [3]int
+	for i, bar := range bars {
+		if shown[i] {
+			visible = append(visible, bar)
+		} else {
+			hidden[phaseOf(bar)]++
+		}
+	}
+	summary = hiddenSummary(hidden)
+	return
+}
+
+type barPhase int
+
+const (
+	phaseRunning barPhase = iota
+	phaseQueued
+	phaseDone
+)
+
+// phaseOf returns the phase of a bar. A bar which failed or was
+// cancelled is done, it will never progress again.
+func phaseOf(bar MiniResizeableBar) barPhase {
+	if bar.Completed() || barErr(bar) != nil {
+		return phaseDone
+	}
+	if min, _, pos := bar.State(); pos > min {
+		return phaseRunning
+	}
+	return phaseQueued
+}
+
+func hiddenSummary(hidden [3]int) string {
+	total := hidden[phaseRunning] + hidden[phaseQueued] + hidden[phaseDone]
+
+	var parts []string
+	for _, it := range []struct {
+		phase barPhase
+		name  string
+	}{
+		{phaseDone, " done"},
+		{phaseRunning, " running"},
+		{phaseQueued, " queued"},
+	} {
+		if n := hidden[it.phase]; n > 0 {
+			parts = append(parts, strconv.Itoa(n)+it.name)
+		}
+	}
+	return "… and " + strconv.Itoa(total) + " more (" + strings.Join(parts, ", ") + ")"
+}
+
+// rowsBelow returns the rows left to the bars within maxRows lines,
+// after the lines taken by titles. At least two rows are left for a
+// bar and the summary. It's zero (unlimited) if maxRows is.
+func rowsBelow(maxRows int, titles ...string) int {
+	if maxRows <= 0 {
+		return 0
+	}
+	for _, title := range titles {
+		maxRows -= strings.Count(title, "\n") + 1
+	}
+	return max(maxRows, 2)
+}
+
+// maxLiveRows returns how many rows can be used by the live area,
+// which is redrawn by moving the cursor up. It's zero (unlimited)
+// if the terminal size is unknown.
+func maxLiveRows() int {
+	if _, rows := termSize(); rows > 1 {
+		return rows - 1
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package progressbar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hedzr/progressbar/v2/pbtest"
+)
+
+func TestViewport(t *testing.T) {
+	var bars []*pbar
+	for i := range 20 {
+		pb := &pbar{max: 100, title: "Task " + intfmt(int64(i))}
+		switch {
+		case i < 9:
+			pb.read, pb.completed = 100, true
+		case i == 12 || i == 15:
+			pb.read = 30
+		}
+		bars = append(bars, pb)
+	}
+
+	visible, summary := viewport(bars, 0)
+	if len(visible) != len(bars) || summary != "" {
+		t.Fatalf("expect all bars visible without limit, but got %d, %q", len(visible), summary)
+	}
+
+	visible, summary = viewport(bars, 5)
+	if len(visible) != 4 {
+		t.Fatalf("expect 4 bars visible, but got %d", len(visible))
+	}
+	if visible[0] != bars[9] || visible[1] != bars[10] || visible[2] != bars[12] || visible[3] != bars[15] {
+		t.Fatalf("expect running bars kept visible in order, but got %v, %v, %v, %v",
+			visible[0].title, visible[1].title, visible[2].title, visible[3].title)
+	}
+	if expect := "… and 16 more (9 done, 7 queued)"; summary != expect {
+		t.Fatalf("expect summary %q, but got %q", expect, summary)
+	}
+}
+
+func TestViewportFailed(t *testing.T) {
+	var bars []*pbar
+	for i := range 4 {
+		bars = append(bars, &pbar{max: 100, read: 30, title: "Task " + intfmt(int64(i))})
+	}
+	bars[0].err = errors.New("boom")
+
+	visible, summary := viewport(bars, 3)
+	if len(visible) != 2 || visible[0] != bars[1] || visible[1] != bars[2] {
+		t.Fatalf("expect the running bars kept visible before the failed one, but got %d", len(visible))
+	}
+	if expect := "… and 2 more (1 done, 1 running)"; summary != expect {
+		t.Fatalf("expect summary %q, but got %q", expect, summary)
+	}
+}
+
+func TestRowsBelow(t *testing.T) {
+	for _, c := range []struct {
+		maxRows int
+		titles  []string
+		expect  int
+	}{
+		{0, []string{"Group"}, 0},
+		{9, []string{"Group"}, 8},
+		{9, []string{"Group\n-----", "Other"}, 6},
+		{3, []string{"a", "b", "c"}, 2},
+	} {
+		if got := rowsBelow(c.maxRows, c.titles...); got != c.expect {
+			t.Fatalf("rowsBelow(%d, %q): expect %d, but got %d", c.maxRows, c.titles, c.expect, got)
+		}
+	}
+}
+
+func TestGroupViewport(t *testing.T) {
+	fixTermSize(t, 80, 10)
+
+	job := func(bar *MPBV2, grp *GroupV2, tsk *TaskBar, progress int64, args ...any) (delta int64, err error) {
+		return 50, nil
+	}
+
+	term := pbtest.New(80, 10)
+	mpb := NewV2(WithOutput(term), WithRenderMode(RenderTTY), WithRefreshInterval(time.Millisecond))
+	for i := range 20 {
+		_ = mpb.AddBar("Group", "Task #"+intfmt(int64(i)), 0, 100, job)
+	}
+	mpb.Run(context.Background())
+	mpb.Close()
+
+	if lines := term.Scrollback(); len(lines) > 0 {
+		t.Fatalf("expect the group fitting the screen, but got %q scrolled out", lines)
+	}
+	if lines := term.Lines(); lines[0] != "Group" {
+		t.Fatalf("expect the group title kept on screen, but got %q", lines)
+	}
+}